@@ -0,0 +1,247 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---- Artifact(작업 결과 파일) ---------------------------------------------------
+
+// Artifact: task에 첨부된 바이너리. 내용은 sha256으로 주소 지정되고, URL은 조회 시마다 새로 서명된다.
+type Artifact struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mime_type"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+	URL      string `json:"url,omitempty"` // 서명된 단기 다운로드 URL(저장 안 함)
+}
+
+var (
+	ErrArtifactNotFound = errors.New("artifact not found")
+	ErrBadArtifactURL   = errors.New("invalid or expired artifact url")
+)
+
+// DefaultArtifactTTL: 서명 URL 유효 시간
+const DefaultArtifactTTL = 15 * time.Minute
+
+// ArtifactStore: dir/<sha 앞 2자리>/<sha> 에 내용 저장(같은 내용은 한 번만). 다운로드는 BaseURL/artifacts/<sha>.
+type ArtifactStore struct {
+	dir     string
+	secret  []byte
+	BaseURL string        // 외부에서 접근 가능한 이 에이전트 주소
+	TTL     time.Duration // 서명 URL 유효 시간(0이면 DefaultArtifactTTL)
+}
+
+// NewArtifactStore: secret이 비어 있으면 dir/.secret을 읽고, 없으면 만들어 둔다
+// (재시작해도 이미 내준 서명 URL이 유효하도록).
+func NewArtifactStore(dir string, secret []byte, baseURL string) (*ArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if len(secret) == 0 {
+		var err error
+		if secret, err = storeSecret(filepath.Join(dir, ".secret")); err != nil {
+			return nil, fmt.Errorf("artifact store: %w", err)
+		}
+	}
+	return &ArtifactStore{dir: dir, secret: secret, BaseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func storeSecret(file string) ([]byte, error) {
+	if b, err := os.ReadFile(file); err == nil && len(bytes.TrimSpace(b)) > 0 {
+		return bytes.TrimSpace(b), nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	secret := []byte(hex.EncodeToString(buf))
+	// 동시에 뜬 프로세스가 먼저 만들었으면 그쪽 값을 쓴다
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return storeSecret(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(secret); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return secret, f.Close()
+}
+
+func (s *ArtifactStore) path(sum string) string {
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+// Put: 내용을 해시하며 임시 파일에 쓰고 sha 경로로 rename
+func (s *ArtifactStore) Put(name, mimeType string, r io.Reader) (Artifact, error) {
+	return s.put(name, mimeType, r, nil)
+}
+
+// put: check가 있으면 임시 파일의 해시/크기를 확인한 뒤에만 저장소에 넣는다
+func (s *ArtifactStore) put(name, mimeType string, r io.Reader, check func(Artifact) error) (Artifact, error) {
+	tmp, err := os.CreateTemp(s.dir, "put-*")
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer os.Remove(tmp.Name()) // rename 후에는 no-op
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return Artifact{}, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(path.Ext(name))
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	a := Artifact{Name: name, MimeType: mimeType, Size: n, SHA256: sum}
+	if check != nil {
+		if err := check(a); err != nil {
+			return Artifact{}, err
+		}
+	}
+	if _, err := os.Stat(s.path(sum)); err == nil {
+		return a, nil // 이미 있음
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path(sum)), 0o755); err != nil {
+		return Artifact{}, err
+	}
+	return a, os.Rename(tmp.Name(), s.path(sum))
+}
+
+// PutBytes: 메모리 내용 저장
+func (s *ArtifactStore) PutBytes(name, mimeType string, b []byte) (Artifact, error) {
+	return s.Put(name, mimeType, bytes.NewReader(b))
+}
+
+func (s *ArtifactStore) Open(sum string) (*os.File, error) {
+	if !validSHA(sum) {
+		return nil, ErrArtifactNotFound
+	}
+	f, err := os.Open(s.path(sum))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrArtifactNotFound
+	}
+	return f, err
+}
+
+// Sign: a에 TTL 동안 유효한 다운로드 URL을 채워 반환
+func (s *ArtifactStore) Sign(a Artifact) Artifact {
+	ttl := s.TTL
+	if ttl <= 0 {
+		ttl = DefaultArtifactTTL
+	}
+	exp := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	q := url.Values{"name": {a.Name}, "type": {a.MimeType}, "exp": {exp}}
+	q.Set("sig", s.sig(a.SHA256, a.Name, a.MimeType, exp))
+	a.URL = s.BaseURL + "/artifacts/" + a.SHA256 + "?" + q.Encode()
+	return a
+}
+
+// SignAll: 목록 전체 서명(조회 응답용 사본)
+func (s *ArtifactStore) SignAll(as []Artifact) []Artifact {
+	if len(as) == 0 {
+		return nil
+	}
+	out := make([]Artifact, len(as))
+	for i, a := range as {
+		out[i] = s.Sign(a)
+	}
+	return out
+}
+
+func (s *ArtifactStore) sig(sum, name, mimeType, exp string) string {
+	return MakeHMACSHA256(s.secret, []byte(sum+"\n"+name+"\n"+mimeType+"\n"+exp))
+}
+
+// ServeHTTP: GET /artifacts/{sha}?name=&type=&exp=&sig= — 서명/만료 확인 후 내용 전송
+func (s *ArtifactStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sum := path.Base(r.URL.Path)
+	q := r.URL.Query()
+	name, typ, exp := q.Get("name"), q.Get("type"), q.Get("exp")
+	e, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > e || !VerifyHMACSHA256(s.secret, []byte(sum+"\n"+name+"\n"+typ+"\n"+exp), q.Get("sig")) {
+		http.Error(w, ErrBadArtifactURL.Error(), http.StatusForbidden)
+		return
+	}
+	f, err := s.Open(sum)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	w.Header().Set("Content-Type", typ)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": name}))
+	w.Header().Set("Cache-Control", "private, max-age=60")
+	w.Header().Set("ETag", `"`+sum+`"`)
+	http.ServeContent(w, r, "", time.Time{}, f)
+}
+
+// Import: 다른 에이전트의 서명 URL에서 내려받아 이 저장소에 저장(크기/sha256 검증)
+func (s *ArtifactStore) Import(ctx context.Context, hc *http.Client, a Artifact) (Artifact, error) {
+	if !validSHA(a.SHA256) {
+		return Artifact{}, NewError(ErrValidationFailed, fmt.Sprintf("artifact %s: invalid sha256 %q", a.Name, a.SHA256))
+	}
+	if a.Size < 0 {
+		return Artifact{}, NewError(ErrValidationFailed, fmt.Sprintf("artifact %s: invalid size %d", a.Name, a.Size))
+	}
+	if _, err := os.Stat(s.path(a.SHA256)); err == nil {
+		a.URL = ""
+		return a, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Artifact{}, fmt.Errorf("artifact %s: http %d", a.Name, resp.StatusCode)
+	}
+	// 선언한 크기보다 1바이트 더 읽어 초과를 알아내고, 해시/크기가 맞을 때만 저장소에 넣는다
+	return s.put(a.Name, a.MimeType, io.LimitReader(resp.Body, a.Size+1), func(got Artifact) error {
+		if got.SHA256 != a.SHA256 || got.Size != a.Size {
+			return fmt.Errorf("artifact %s: content mismatch (sha256 %s, size %d)", a.Name, got.SHA256, got.Size)
+		}
+		return nil
+	})
+}
+
+func validSHA(sum string) bool {
+	if len(sum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
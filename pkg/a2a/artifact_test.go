@@ -0,0 +1,87 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestArtifactImport(t *testing.T) {
+	body := []byte("%PDF-1.4 label")
+	sum := sha256.Sum256(body)
+	good := Artifact{Name: "l.pdf", MimeType: "application/pdf", Size: int64(len(body)), SHA256: hex.EncodeToString(sum[:])}
+	other := sha256.Sum256([]byte("other"))
+
+	tests := []struct {
+		name    string
+		serve   []byte
+		a       Artifact
+		wantErr bool
+	}{
+		{"ok", body, good, false},
+		{"digest mismatch", []byte("%PDF-1.4 lab3l"), good, true},
+		{"body longer than size", append(bytes.Clone(body), "trailing"...), good, true},
+		{"body shorter than size", body[:4], good, true},
+		{"wrong declared digest", body, Artifact{Name: "l.pdf", Size: good.Size, SHA256: hex.EncodeToString(other[:])}, true},
+		{"invalid digest", body, Artifact{Name: "l.pdf", Size: good.Size, SHA256: "../../etc"}, true},
+		{"negative size", body, Artifact{Name: "l.pdf", Size: -1, SHA256: good.SHA256}, true},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		s, err := NewArtifactStore(dir, []byte("k"), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(tt.serve) }))
+		a := tt.a
+		a.URL = srv.URL
+		got, err := s.Import(context.Background(), srv.Client(), a)
+		srv.Close()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v", tt.name, err)
+			continue
+		}
+		if tt.wantErr {
+			// 검증에 실패한 내용은 저장소에도, 임시 파일로도 남지 않는다
+			for _, a := range []Artifact{good, tt.a} {
+				if f, err := s.Open(a.SHA256); err == nil {
+					f.Close()
+					t.Errorf("%s: %s was stored", tt.name, a.SHA256)
+				}
+			}
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if e.IsDir() || strings.HasPrefix(e.Name(), "put-") {
+					t.Errorf("%s: leftover %s", tt.name, e.Name())
+				}
+			}
+			continue
+		}
+		if got.SHA256 != good.SHA256 || got.Size != good.Size || got.URL != "" {
+			t.Errorf("%s: got %+v", tt.name, got)
+		}
+		// 이미 있으면 다시 받지 않는다
+		if again, err := s.Import(context.Background(), nil, Artifact{Name: "l.pdf", Size: good.Size, SHA256: good.SHA256, URL: "http://127.0.0.1:1/"}); err != nil || again.SHA256 != good.SHA256 {
+			t.Errorf("%s: second import = %+v, %v", tt.name, again, err)
+		}
+	}
+}
+
+func TestArtifactImportHTTPError(t *testing.T) {
+	s, _ := NewArtifactStore(t.TempDir(), []byte("k"), "")
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	sum := sha256.Sum256(nil)
+	_, err := s.Import(context.Background(), nil, Artifact{Name: "x", SHA256: hex.EncodeToString(sum[:]), URL: srv.URL})
+	var ep *ErrorPayload
+	if err == nil || errors.As(err, &ep) {
+		t.Errorf("err = %v, want http error", err)
+	}
+}
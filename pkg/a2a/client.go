@@ -0,0 +1,175 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Client: 다른 에이전트 호출용 SDK. 헤더(agent id/trace/deadline/서명), 오류 해석, 재시도를 담당.
+type Client struct {
+	HTTP           *http.Client
+	AgentID        string
+	Signer         *Signer
+	Retry          RetryPolicy   // 호출별 정책이 없을 때 쓰는 기본 정책
+	Budget         *RetryBudget  // 이 클라이언트의 모든 호출이 공유(nil이면 무제한)
+	AttemptTimeout time.Duration // 시도별 마감(0이면 HTTP.Timeout과 호출자 마감만)
+}
+
+func NewClient(agentID string, signer *Signer) *Client {
+	return &Client{
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+		AgentID: agentID,
+		Signer:  signer,
+		Retry:   DefaultRetryPolicy,
+		Budget:  NewRetryBudget(0.2, 10),
+	}
+}
+
+// CreateTask: POST {base}/tasks → ack(task_id, status). idempotency_key가 없으면 붙여서
+// 재시도(예: SHIP)가 에이전트에서 한 번만 처리되게 한다. p가 nil이면 c.Retry.
+func (c *Client) CreateTask(ctx context.Context, baseURL string, ct CreateTask, p *RetryPolicy) (*Task, error) {
+	if ct.IdempotencyKey == "" {
+		ct.IdempotencyKey = NewID("idem_")
+	}
+	if ct.ContextID == "" {
+		ct.ContextID = ContextID(ctx)
+	}
+	body, err := json.Marshal(ct)
+	if err != nil {
+		return nil, err
+	}
+	var ack Task
+	err = Retry(ctx, c.policy(p), c.Budget, func(ctx context.Context, _ int) error {
+		return c.do(ctx, http.MethodPost, baseURL+"/tasks", body, &ack)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ack, nil
+}
+
+// GetTask: GET {base}/tasks/{id}
+func (c *Client) GetTask(ctx context.Context, baseURL, id string, p *RetryPolicy) (*Task, error) {
+	var t Task
+	err := Retry(ctx, c.policy(p), c.Budget, func(ctx context.Context, _ int) error {
+		return c.do(ctx, http.MethodGet, baseURL+"/tasks/"+id, nil, &t)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SendMessage: POST {base}/tasks/{id}/messages → 갱신된 Task. 재시도하지 않는다(같은 답이 두 번 쌓이지 않게).
+func (c *Client) SendMessage(ctx context.Context, baseURL, id string, m Message) (*Task, error) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var t Task
+	if err := c.do(ctx, http.MethodPost, baseURL+"/tasks/"+id+"/messages", body, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (c *Client) policy(p *RetryPolicy) RetryPolicy {
+	if p == nil {
+		return c.Retry.Merge(DefaultRetryPolicy)
+	}
+	return p.Merge(c.Retry.Merge(DefaultRetryPolicy))
+}
+
+// do: 한 번의 시도. 실패는 *ErrorPayload(재시도 판단용 코드 포함) 또는 호출자 ctx 오류.
+func (c *Client) do(ctx context.Context, method, url string, body []byte, out any) error {
+	actx := ctx
+	if c.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		actx, cancel = context.WithTimeout(ctx, c.AttemptTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(actx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.AgentID != "" {
+		req.Header.Set(HeaderAgentID, c.AgentID)
+	}
+	if tr := TraceID(ctx); tr != "" {
+		req.Header.Set(HeaderTraceID, tr)
+	}
+	SetDeadline(req)
+	if err := c.Signer.Sign(req, body); err != nil {
+		return err
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err() // 호출자 취소/마감 — 재시도 안 함
+		}
+		var ne interface{ Timeout() bool }
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+			return NewError(ErrTimeout, err.Error())
+		}
+		return NewError(ErrUnavailable, err.Error())
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return NewError(ErrUnavailable, err.Error())
+	}
+	if resp.StatusCode >= 300 {
+		return responseError(url, resp.StatusCode, b)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return NewError(ErrInternal, fmt.Sprintf("%s: bad response: %v", url, err))
+	}
+	return nil
+}
+
+// responseError: 에이전트는 오류를 Task{error} 또는 ErrorPayload로 돌려준다. 둘 다 아니면 HTTP 상태로 분류.
+func responseError(url string, status int, body []byte) *ErrorPayload {
+	var t Task
+	if json.Unmarshal(body, &t) == nil && t.Error != nil && t.Error.Code != "" {
+		return t.Error
+	}
+	var ep ErrorPayload
+	if json.Unmarshal(body, &ep) == nil && ep.Code != "" {
+		return &ep
+	}
+	msg := fmt.Sprintf("%s: http %d", url, status)
+	switch {
+	case status == 408 || status == 504:
+		return NewError(ErrTimeout, msg)
+	case status == 429 || status == 502 || status == 503:
+		return NewError(ErrUnavailable, msg)
+	case status == 401:
+		return NewError(ErrUnauthorized, msg)
+	case status == 403:
+		return NewError(ErrForbidden, msg)
+	case status == 404:
+		return NewError(ErrNotFound, msg)
+	case status == 409:
+		return NewError(ErrConflict, msg)
+	case status >= 500:
+		return NewError(ErrInternal, msg)
+	default:
+		return NewError(ErrValidationFailed, msg)
+	}
+}
@@ -0,0 +1,56 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ---- 마감 전파 -------------------------------------------------------------------
+//
+// 마감은 절대 시각이 아니라 "남은 ms"로 보낸다(에이전트 간 시계 오차 무관).
+// 보내는 쪽은 요청 직전 ctx의 남은 시간을 넣고, 받는 쪽은 도착 시각 기준으로 ctx 마감을 건다.
+// 따라서 각 홉에서 이미 쓴 시간은 자연히 빠진다.
+
+// DeadlineMiddleware: X-Agent-Deadline-Ms를 요청 ctx의 마감으로 설정. 이미 지났으면 504 TIMEOUT.
+func DeadlineMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := r.Header.Get(HeaderDeadline)
+		if v == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(NewError(ErrValidationFailed, "bad "+HeaderDeadline))
+			return
+		}
+		if ms <= 0 {
+			w.WriteHeader(504)
+			json.NewEncoder(w).Encode(NewError(ErrTimeout, "deadline already exceeded"))
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), time.Duration(ms)*time.Millisecond)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// SetDeadline: 요청 ctx에 마감이 있으면 남은 시간을 헤더에 기록
+func SetDeadline(req *http.Request) {
+	if dl, ok := req.Context().Deadline(); ok {
+		req.Header.Set(HeaderDeadline, strconv.FormatInt(max(time.Until(dl).Milliseconds(), 0), 10))
+	}
+}
+
+// Detach: 취소는 끊고 마감과 값(trace 등)은 유지 — 응답 후에도 이어지는 비동기 작업용
+func Detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	bg := context.WithoutCancel(ctx)
+	if dl, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(bg, dl)
+	}
+	return context.WithCancel(bg)
+}
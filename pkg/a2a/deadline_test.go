@@ -0,0 +1,177 @@
+package a2a
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// remainingHandler: 핸들러가 본 ctx의 남은 시간(마감 없으면 -1)
+func remainingHandler(got *time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*got = -1
+		if dl, ok := r.Context().Deadline(); ok {
+			*got = time.Until(dl)
+		}
+		w.WriteHeader(204)
+	})
+}
+
+func TestDeadlineMiddleware(t *testing.T) {
+	cases := []struct {
+		header string
+		code   string // "" = 통과
+		lo, hi time.Duration
+	}{
+		{"", "", -1, -1},
+		{"1500", "", 1400 * time.Millisecond, 1500 * time.Millisecond},
+		{"0", ErrTimeout, 0, 0},
+		{"-20", ErrTimeout, 0, 0},
+		{"soon", ErrValidationFailed, 0, 0},
+		{"1.5", ErrValidationFailed, 0, 0},
+	}
+	for _, c := range cases {
+		var got time.Duration
+		called := false
+		h := DeadlineMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			remainingHandler(&got).ServeHTTP(w, r)
+		}))
+		req := httptest.NewRequest("POST", "/tasks", nil)
+		if c.header != "" {
+			req.Header.Set(HeaderDeadline, c.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if c.code != "" {
+			var ep ErrorPayload
+			json.NewDecoder(rec.Body).Decode(&ep)
+			if called || ep.Code != c.code || (c.code == ErrTimeout) != (rec.Code == 504) {
+				t.Errorf("%q: status %d code %q called %v, want %s", c.header, rec.Code, ep.Code, called, c.code)
+			}
+			continue
+		}
+		if !called || got < c.lo || got > c.hi {
+			t.Errorf("%q: called %v, remaining %v, want [%v, %v]", c.header, called, got, c.lo, c.hi)
+		}
+	}
+}
+
+func TestSetDeadline(t *testing.T) {
+	req := httptest.NewRequest("POST", "/tasks", nil)
+	SetDeadline(req)
+	if v := req.Header.Get(HeaderDeadline); v != "" {
+		t.Errorf("no deadline: header %q", v)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 800*time.Millisecond)
+	defer cancel()
+	req = req.WithContext(ctx)
+	SetDeadline(req)
+	if ms, _ := strconv.Atoi(req.Header.Get(HeaderDeadline)); ms <= 700 || ms > 800 {
+		t.Errorf("remaining %q ms, want (700, 800]", req.Header.Get(HeaderDeadline))
+	}
+
+	expired, cancel2 := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel2()
+	req = req.WithContext(expired)
+	SetDeadline(req)
+	if v := req.Header.Get(HeaderDeadline); v != "0" {
+		t.Errorf("expired: header %q, want 0", v)
+	}
+}
+
+// 홉마다 쓴 시간만큼 남은 마감이 줄어 다음 홉에 전달된다
+func TestDeadlinePropagation(t *testing.T) {
+	var last time.Duration
+	leaf := httptest.NewServer(DeadlineMiddleware(remainingHandler(&last)))
+	defer leaf.Close()
+
+	// hop: work만큼 일한 뒤 받은 마감을 그대로 next에 전달
+	hop := func(work time.Duration, next string) http.Handler {
+		return DeadlineMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(work)
+			req, _ := http.NewRequestWithContext(r.Context(), "POST", next, nil)
+			SetDeadline(req)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				w.WriteHeader(502)
+				return
+			}
+			resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+		}))
+	}
+	call := func(h http.Handler) int {
+		req := httptest.NewRequest("POST", "/tasks", nil)
+		req.Header.Set(HeaderDeadline, "1000")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call(hop(150*time.Millisecond, leaf.URL)); code != 204 {
+		t.Fatalf("status %d", code)
+	}
+	if last > 850*time.Millisecond || last < 500*time.Millisecond {
+		t.Errorf("leaf saw %v remaining, want about 850ms", last)
+	}
+	// 두 홉을 거치면 두 번 빠진다
+	mid := httptest.NewServer(hop(100*time.Millisecond, leaf.URL))
+	defer mid.Close()
+	if code := call(hop(100*time.Millisecond, mid.URL)); code != 204 {
+		t.Fatalf("status %d", code)
+	}
+	if last > 800*time.Millisecond || last < 400*time.Millisecond {
+		t.Errorf("leaf saw %v remaining after two hops, want about 800ms", last)
+	}
+}
+
+type ctxKey struct{}
+
+func TestDetach(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey{}, "tr_1"), time.Second)
+	dl, _ := parent.Deadline()
+	ctx, stop := Detach(parent)
+	defer stop()
+	cancel()
+
+	if ctx.Err() != nil {
+		t.Fatalf("detached ctx canceled with parent: %v", ctx.Err())
+	}
+	if ctx.Value(ctxKey{}) != "tr_1" {
+		t.Error("value lost")
+	}
+	if got, ok := ctx.Deadline(); !ok || !got.Equal(dl) {
+		t.Errorf("deadline = %v %v, want %v", got, ok, dl)
+	}
+	stop()
+	if ctx.Err() != context.Canceled {
+		t.Errorf("own cancel: err = %v", ctx.Err())
+	}
+
+	// 마감 없는 부모 → 마감 없이 자체 cancel만
+	parent, cancel = context.WithCancel(context.Background())
+	ctx, stop = Detach(parent)
+	cancel()
+	if _, ok := ctx.Deadline(); ok || ctx.Err() != nil {
+		t.Errorf("no-deadline parent: deadline %v, err %v", ok, ctx.Err())
+	}
+	stop()
+	if ctx.Err() == nil {
+		t.Error("cancel func did nothing")
+	}
+
+	// 이미 지난 마감은 그대로 지켜진다
+	parent, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Millisecond))
+	defer cancel()
+	ctx, stop = Detach(parent)
+	defer stop()
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("expired parent: err = %v", ctx.Err())
+	}
+}
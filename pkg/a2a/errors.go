@@ -8,6 +8,7 @@ const (
 	ErrNotFound         = "NOT_FOUND"
 	ErrConflict         = "CONFLICT" // 멱등 충돌 등
 	ErrInternal         = "INTERNAL"
+	ErrUnavailable      = "UNAVAILABLE" // 연결 실패, 429/502/503 — 재시도 대상
 )
 
 type ErrorPayload struct {
@@ -19,3 +20,11 @@ type ErrorPayload struct {
 func NewError(code, msg string) *ErrorPayload {
 	return &ErrorPayload{Code: code, Message: msg}
 }
+
+// error 인터페이스 구현 — 원격 오류 코드를 errors.As로 꺼낼 수 있게
+func (e *ErrorPayload) Error() string {
+	if e.Hint != "" {
+		return e.Code + ": " + e.Message + " (" + e.Hint + ")"
+	}
+	return e.Code + ": " + e.Message
+}
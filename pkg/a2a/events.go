@@ -0,0 +1,111 @@
+package a2a
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+func NewProgressEvent(taskID string, percent int, msg string) Event {
+	b, _ := json.Marshal(ProgressPayload{Percent: percent, Message: msg})
+	return Event{Event: EventTaskProgress, TaskID: taskID, Payload: b}
+}
+
+func NewCompletedEvent(taskID string, result json.RawMessage) Event {
+	return Event{Event: EventTaskCompleted, TaskID: taskID, Payload: result}
+}
+
+func NewInputRequiredEvent(taskID string, req *InputRequest) Event {
+	b, _ := json.Marshal(req)
+	return Event{Event: EventInputRequired, TaskID: taskID, Payload: b}
+}
+
+func NewFailedEvent(taskID string, e *ErrorPayload) Event {
+	b, _ := json.Marshal(FailedPayload{Error: e})
+	return Event{Event: EventTaskFailed, TaskID: taskID, Payload: b}
+}
+
+// ValidateEvent: 이벤트 종류별 payload 형식 검사
+func ValidateEvent(ev *Event) error {
+	switch ev.Event {
+	case EventTaskProgress:
+		var p ProgressPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return fmt.Errorf("progress payload: %w", err)
+		}
+		if p.Percent < 0 || p.Percent > 100 {
+			return errors.New("progress percent must be 0..100")
+		}
+	case EventTaskCompleted:
+	case EventTaskFailed:
+		var p FailedPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return fmt.Errorf("failed payload: %w", err)
+		}
+		if p.Error == nil || p.Error.Code == "" {
+			return errors.New("failed payload requires error.code")
+		}
+	case EventInputRequired:
+		var p InputRequest
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return fmt.Errorf("input required payload: %w", err)
+		}
+		if len(p.Fields) == 0 {
+			return errors.New("input required payload requires fields")
+		}
+	case EventTrackingUpdate:
+		var p TrackingPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return fmt.Errorf("tracking payload: %w", err)
+		}
+		if p.TrackingID == "" || p.Checkpoint.Status == "" {
+			return errors.New("tracking payload requires tracking_id and checkpoint.status")
+		}
+	default:
+		return fmt.Errorf("unknown event %q", ev.Event)
+	}
+	return nil
+}
+
+// EventStatus: 이벤트가 적용되면 Task가 가져야 할 상태
+func EventStatus(t EventType) TaskStatus {
+	switch t {
+	case EventTaskCompleted:
+		return StatusSucceeded
+	case EventTaskFailed:
+		return StatusFailed
+	case EventInputRequired:
+		return StatusInputRequired
+	default:
+		return StatusRunning
+	}
+}
+
+// PostEvent: reply_url로 이벤트 콜백 전송(2xx가 아니면 오류). signer가 있으면 HMAC 서명.
+func PostEvent(ctx context.Context, replyURL string, ev Event, signer *Signer) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, replyURL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	SetDeadline(req)
+	if err := signer.Sign(req, b); err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("event callback %s: http %d", replyURL, resp.StatusCode)
+	}
+	return nil
+}
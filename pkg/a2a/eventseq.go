@@ -0,0 +1,202 @@
+package a2a
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 보관 한도 — 오래 조용한 작업은 통째로, 긴 작업은 오래된 이벤트부터 버린다
+const (
+	DefaultEventRetention = time.Hour            // 마지막 이벤트 뒤 이만큼 지나면 정리
+	DefaultEventsPerTask  = 1000                 // 작업별 재전송용 보관 수
+	maxSeenPerKey         = 1024                 // 수신 측 seq 없는 이벤트 event_id 기억 수
+	maxPendingPerKey      = DefaultEventsPerTask // 수신 측 버퍼링 창 — next+이만큼 앞선 seq는 거절
+	sweepEvery            = time.Minute
+)
+
+// ---- 발신 측: 작업별 seq 부여 + 재전송용 보관 ------------------------------------
+
+type EventLog struct {
+	mu     sync.Mutex
+	events map[string]*taskEvents
+	swept  time.Time
+}
+
+type taskEvents struct {
+	evs  []Event // seq 순, evs[0].Seq = dropped+1
+	seq  int64   // 마지막으로 부여한 seq
+	last time.Time
+}
+
+func NewEventLog() *EventLog {
+	return &EventLog{events: map[string]*taskEvents{}}
+}
+
+// Append: event_id/seq/timestamp를 채워 기록하고 채워진 이벤트를 반환
+func (l *EventLog) Append(ev Event) Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now().UTC()
+	l.sweep(now)
+	te := l.events[ev.TaskID]
+	if te == nil {
+		te = &taskEvents{}
+		l.events[ev.TaskID] = te
+	}
+	te.seq++
+	ev.EventID = NewID("ev_")
+	ev.Seq = te.seq
+	ev.Timestamp = now
+	te.evs = append(te.evs, ev)
+	if n := len(te.evs) - DefaultEventsPerTask; n > 0 {
+		te.evs = append([]Event(nil), te.evs[n:]...)
+	}
+	te.last = now
+	return ev
+}
+
+// Since: seq > since 인 이벤트(순서대로). 이미 버린 구간은 건너뛴다.
+func (l *EventLog) Since(taskID string, since int64) []Event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	te := l.events[taskID]
+	if te == nil || since >= te.seq {
+		return []Event{}
+	}
+	first := te.evs[0].Seq
+	if since < first-1 {
+		since = first - 1
+	}
+	return append([]Event(nil), te.evs[since-first+1:]...)
+}
+
+// sweep: 보관 기간이 지난 작업 정리(mu 잡은 채로, 최대 분당 한 번)
+func (l *EventLog) sweep(now time.Time) {
+	if now.Sub(l.swept) < sweepEvery {
+		return
+	}
+	l.swept = now
+	for id, te := range l.events {
+		if now.Sub(te.last) > DefaultEventRetention {
+			delete(l.events, id)
+		}
+	}
+}
+
+// ---- 수신 측: event_id 중복 제거 + seq 순서 적용 -----------------------------------
+
+// EventInbox: seq가 비어 있으면(gap) 뒤 이벤트를 버퍼링했다가 빈 곳이 채워지면 함께 적용한다.
+// seq가 0인 이벤트는 순서 보장 없이 즉시 적용한다.
+type EventInbox struct {
+	mu    sync.Mutex
+	keys  map[string]*inboxKey
+	swept time.Time
+}
+
+type inboxKey struct {
+	next    int64           // 다음에 적용할 seq
+	seen    map[string]bool // 적용한 seq 없는 이벤트의 event_id
+	order   []string        // seen 입력 순서(오래된 것부터 버림)
+	pending map[int64]Event // 버퍼링된(또는 적용에 실패한) 이벤트
+	last    time.Time
+}
+
+func NewEventInbox() *EventInbox {
+	return &EventInbox{keys: map[string]*inboxKey{}}
+}
+
+// Accept: key(보통 task_id)별로 이벤트를 받아 지금 적용 가능한 이벤트를 순서대로 apply에 넘긴다.
+// 적용에 성공한 이벤트만 받은 것으로 기록한다 — apply가 실패하면 그 이벤트부터는 버퍼에 남아
+// 같은 이벤트가 재전송되면 다시 시도된다. 단 *ErrorPayload(이벤트 자체가 거절됨, 예: 잘못된 전이)는
+// 다시 보내도 같으므로 처리한 것으로 치고 뒤 이벤트를 계속 적용한 뒤 그 오류를 돌려준다.
+// 이미 적용한 이벤트면 dup=true, 버퍼링만 했으면 applied=0. 다음 seq보다 maxPendingPerKey 이상
+// 앞선 이벤트는 버퍼링하지 않고 CONFLICT로 거절한다(빈 구간이 채워진 뒤 재전송하면 받는다).
+// apply는 inbox 잠금 안에서 불리므로 짧고 막히지 않아야 한다.
+func (in *EventInbox) Accept(key string, ev Event, apply func(Event) error) (applied int, dup bool, err error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	now := time.Now()
+	in.sweep(now)
+	k := in.keys[key]
+	if k == nil {
+		k = &inboxKey{next: 1, seen: map[string]bool{}, pending: map[int64]Event{}}
+		in.keys[key] = k
+	}
+	k.last = now
+
+	if ev.Seq == 0 {
+		if ev.EventID != "" && k.seen[ev.EventID] {
+			return 0, true, nil
+		}
+		err := apply(ev)
+		if err != nil && !rejected(err) {
+			return 0, false, err
+		}
+		k.remember(ev.EventID)
+		return 1, false, err
+	}
+	if ev.Seq < k.next {
+		return 0, true, nil // 이미 적용된 seq (재전송)
+	}
+	if ev.Seq >= k.next+maxPendingPerKey {
+		return 0, false, NewError(ErrConflict, fmt.Sprintf("seq %d is too far ahead (next %d, window %d)", ev.Seq, k.next, maxPendingPerKey))
+	}
+	k.pending[ev.Seq] = ev
+	for {
+		e, ok := k.pending[k.next]
+		if !ok {
+			break
+		}
+		if aerr := apply(e); aerr != nil {
+			if !rejected(aerr) {
+				return applied, false, aerr
+			}
+			if err == nil {
+				err = aerr
+			}
+		}
+		delete(k.pending, k.next)
+		k.next++
+		applied++
+	}
+	return applied, false, err
+}
+
+func rejected(err error) bool {
+	var ep *ErrorPayload
+	return errors.As(err, &ep)
+}
+
+func (k *inboxKey) remember(id string) {
+	if id == "" {
+		return
+	}
+	k.seen[id] = true
+	k.order = append(k.order, id)
+	if len(k.order) > maxSeenPerKey {
+		delete(k.seen, k.order[0])
+		k.order = k.order[1:]
+	}
+}
+
+// sweep: 오래 이벤트가 없던 key 정리(mu 잡은 채로, 최대 분당 한 번)
+func (in *EventInbox) sweep(now time.Time) {
+	if now.Sub(in.swept) < sweepEvery {
+		return
+	}
+	in.swept = now
+	for key, k := range in.keys {
+		if now.Sub(k.last) > DefaultEventRetention {
+			delete(in.keys, key)
+		}
+	}
+}
+
+// Forget: 끝난 작업의 상태 정리
+func (in *EventInbox) Forget(key string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	delete(in.keys, key)
+}
@@ -0,0 +1,183 @@
+package a2a
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEventLogSinceAndRetention(t *testing.T) {
+	l := NewEventLog()
+	for range DefaultEventsPerTask + 5 {
+		l.Append(Event{TaskID: "t_1", Event: EventTaskProgress})
+	}
+	ev := l.Append(Event{TaskID: "t_2", Event: EventTaskCompleted})
+	if ev.Seq != 1 || ev.EventID == "" || ev.Timestamp.IsZero() {
+		t.Errorf("appended = %+v", ev)
+	}
+
+	tests := []struct {
+		since int64
+		n     int
+		first int64
+	}{
+		{0, DefaultEventsPerTask, 6}, // 오래된 5개는 버림
+		{3, DefaultEventsPerTask, 6},
+		{1000, 5, 1001},
+		{1005, 0, 0},
+		{2000, 0, 0},
+	}
+	for _, tt := range tests {
+		evs := l.Since("t_1", tt.since)
+		if len(evs) != tt.n || (tt.n > 0 && (evs[0].Seq != tt.first || evs[len(evs)-1].Seq != DefaultEventsPerTask+5)) {
+			t.Errorf("Since(%d): %d events", tt.since, len(evs))
+		}
+	}
+	if evs := l.Since("t_unknown", 0); evs == nil || len(evs) != 0 {
+		t.Errorf("unknown task: %v", evs)
+	}
+
+	// 보관 기간이 지난 작업은 다음 sweep에서 정리
+	l.mu.Lock()
+	l.events["t_1"].last = time.Now().Add(-2 * DefaultEventRetention)
+	l.swept = time.Time{}
+	l.mu.Unlock()
+	l.Append(Event{TaskID: "t_2", Event: EventTaskProgress})
+	if evs := l.Since("t_1", 0); len(evs) != 0 {
+		t.Errorf("expired task kept %d events", len(evs))
+	}
+	if evs := l.Since("t_2", 0); len(evs) != 2 {
+		t.Errorf("active task: %d events", len(evs))
+	}
+}
+
+func TestEventInboxOrderAndDedup(t *testing.T) {
+	in := NewEventInbox()
+	var got []int64
+	apply := func(e Event) error { got = append(got, e.Seq); return nil }
+	ev := func(seq int64) Event { return Event{TaskID: "t", Seq: seq, EventID: "ev_" + string(rune('a'+seq))} }
+
+	steps := []struct {
+		seq     int64
+		applied int
+		dup     bool
+	}{
+		{3, 0, false}, // 빈 구간 → 버퍼링
+		{2, 0, false},
+		{3, 0, false}, // 버퍼에 있는 것 재전송
+		{1, 3, false}, // 빈 곳이 채워지면 함께 적용
+		{2, 0, true},  // 이미 적용
+		{4, 1, false},
+	}
+	for i, s := range steps {
+		applied, dup, err := in.Accept("k", ev(s.seq), apply)
+		if err != nil || applied != s.applied || dup != s.dup {
+			t.Errorf("step %d (seq %d): applied = %d, dup = %v, err = %v", i, s.seq, applied, dup, err)
+		}
+	}
+	if len(got) != 4 || got[0] != 1 || got[1] != 2 || got[2] != 3 || got[3] != 4 {
+		t.Errorf("applied order = %v", got)
+	}
+
+	// seq 없는 이벤트는 event_id로 중복 제거
+	for i, want := range []bool{false, true} {
+		if _, dup, _ := in.Accept("k", Event{EventID: "ev_noseq"}, apply); dup != want {
+			t.Errorf("no-seq event #%d: dup = %v", i, dup)
+		}
+	}
+	// 다른 key는 따로 센다
+	if applied, _, _ := in.Accept("other", ev(1), apply); applied != 1 {
+		t.Errorf("other key: applied = %d", applied)
+	}
+}
+
+func TestEventInboxApplyFailures(t *testing.T) {
+	in := NewEventInbox()
+	down := errors.New("store down")
+	var fail error
+	var got []int64
+	apply := func(e Event) error {
+		if fail != nil {
+			return fail
+		}
+		got = append(got, e.Seq)
+		return nil
+	}
+
+	// 일시적 실패: 받은 것으로 치지 않고 버퍼에 남겨 재전송 때 다시 시도
+	fail = down
+	if applied, _, err := in.Accept("k", Event{Seq: 1}, apply); applied != 0 || !errors.Is(err, down) {
+		t.Fatalf("applied = %d, err = %v", applied, err)
+	}
+	fail = nil
+	if applied, dup, err := in.Accept("k", Event{Seq: 1}, apply); applied != 1 || dup || err != nil {
+		t.Fatalf("retry: applied = %d, dup = %v, err = %v", applied, dup, err)
+	}
+
+	// 거절(*ErrorPayload): 처리한 것으로 치고 뒤 이벤트를 계속 적용
+	in.Accept("k", Event{Seq: 3}, apply)
+	fail = NewError(ErrConflict, "illegal transition")
+	applied, _, err := in.Accept("k", Event{Seq: 2}, func(e Event) error {
+		if e.Seq == 2 {
+			return fail
+		}
+		return apply(e)
+	})
+	var ep *ErrorPayload
+	if applied != 2 || !errors.As(err, &ep) || ep.Code != ErrConflict {
+		t.Errorf("rejected: applied = %d, err = %v", applied, err)
+	}
+	fail = nil
+	if _, dup, _ := in.Accept("k", Event{Seq: 2}, apply); !dup {
+		t.Error("rejected event was not consumed")
+	}
+}
+
+// 버퍼링 창을 넘는 seq는 거절해 key별 버퍼가 커지지 않는다
+func TestEventInboxPendingBound(t *testing.T) {
+	in := NewEventInbox()
+	apply := func(Event) error { return nil }
+	for seq := int64(2); seq <= maxPendingPerKey+10; seq++ {
+		_, _, err := in.Accept("k", Event{Seq: seq}, apply)
+		var ep *ErrorPayload
+		if inWindow := seq < 1+maxPendingPerKey; inWindow != (err == nil) || (!inWindow && (!errors.As(err, &ep) || ep.Code != ErrConflict)) {
+			t.Fatalf("seq %d: err = %v", seq, err)
+		}
+	}
+	if n := len(in.keys["k"].pending); n != maxPendingPerKey-1 {
+		t.Errorf("pending = %d, want %d", n, maxPendingPerKey-1)
+	}
+	// 빈 곳이 채워지면 창이 앞으로 움직인다
+	if applied, _, err := in.Accept("k", Event{Seq: 1}, apply); applied != maxPendingPerKey || err != nil {
+		t.Errorf("fill: applied = %d, err = %v", applied, err)
+	}
+	if _, _, err := in.Accept("k", Event{Seq: maxPendingPerKey + 10}, apply); err != nil {
+		t.Errorf("after fill: %v", err)
+	}
+}
+
+func TestEventInboxRetention(t *testing.T) {
+	in := NewEventInbox()
+	apply := func(Event) error { return nil }
+	for i := range maxSeenPerKey + 1 {
+		in.Accept("k", Event{EventID: NewID("ev_")}, apply)
+		if i == 0 {
+			in.Accept("k", Event{EventID: "ev_first"}, apply)
+		}
+	}
+	if k := in.keys["k"]; len(k.seen) != maxSeenPerKey || len(k.order) != maxSeenPerKey || k.seen["ev_first"] {
+		t.Errorf("seen = %d, order = %d", len(k.seen), len(k.order))
+	}
+
+	in.Accept("old", Event{Seq: 1}, apply)
+	in.keys["old"].last = time.Now().Add(-2 * DefaultEventRetention)
+	in.swept = time.Time{}
+	in.Accept("k", Event{Seq: 1}, apply)
+	if _, ok := in.keys["old"]; ok {
+		t.Error("idle key was not swept")
+	}
+	in.Forget("k")
+	if _, ok := in.keys["k"]; ok {
+		t.Error("Forget kept the key")
+	}
+}
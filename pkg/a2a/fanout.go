@@ -0,0 +1,206 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FanOutOptions: scatter-gather 동작 설정 (0 값은 "제한 없음")
+type FanOutOptions struct {
+	Deadline        time.Duration            // 전체 마감
+	PerTarget       time.Duration            // 대상별 기본 마감
+	TargetDeadlines map[string]time.Duration // 대상별 개별 마감(PerTarget보다 우선)
+	FirstK          int                      // 성공 K개가 모이면 나머지 취소 후 즉시 반환
+	Quorum          int                      // 최소 성공 수 — 달성 불가능해지면 조기 종료
+	HedgeAfter      time.Duration            // 이 시간 내 응답이 없으면 같은 대상에 중복 요청
+	MaxHedges       int                      // 대상별 중복 요청 상한(기본 1)
+}
+
+// Outcome: 대상 하나의 결과(성공/오류 코드/타임아웃/지연)
+type Outcome[T any] struct {
+	Target    string        `json:"target"`
+	OK        bool          `json:"ok"`
+	Value     T             `json:"-"`
+	Code      string        `json:"code,omitempty"` // 실패 시 ErrTimeout | ErrInternal | 원격 ErrorPayload.Code
+	Message   string        `json:"message,omitempty"`
+	TimedOut  bool          `json:"timed_out,omitempty"`
+	Canceled  bool          `json:"canceled,omitempty"` // FirstK/Quorum 판정으로 중단됨
+	Attempts  int           `json:"attempts"`           // 1 + hedge 수
+	Hedged    bool          `json:"hedged,omitempty"`   // hedge 요청이 응답을 가져옴
+	Latency   time.Duration `json:"-"`
+	LatencyMS int64         `json:"latency_ms"`
+}
+
+type FanOutResult[T any] struct {
+	Outcomes  []Outcome[T] // targets 순서와 동일
+	Succeeded int
+	QuorumMet bool
+}
+
+// Successes: 성공한 결과 값만 targets 순서대로
+func (r FanOutResult[T]) Successes() []T {
+	out := make([]T, 0, r.Succeeded)
+	for _, o := range r.Outcomes {
+		if o.OK {
+			out = append(out, o.Value)
+		}
+	}
+	return out
+}
+
+// Failures: 실패/타임아웃/취소된 대상
+func (r FanOutResult[T]) Failures() []Outcome[T] {
+	var out []Outcome[T]
+	for _, o := range r.Outcomes {
+		if !o.OK {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// ScatterGather: targets 각각에 call을 병렬 실행하고 대상별 결과를 모은다.
+// call은 전달받은 ctx가 끝나면 즉시 반환해야 한다.
+func ScatterGather[T any](ctx context.Context, targets []string, call func(ctx context.Context, target string) (T, error), opt FanOutOptions) FanOutResult[T] {
+	if opt.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.Deadline)
+		defer cancel()
+	}
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	type indexed struct {
+		i int
+		o Outcome[T]
+	}
+	ch := make(chan indexed, len(targets))
+	for i, target := range targets {
+		go func(i int, target string) {
+			ch <- indexed{i, runTarget(ctx, target, call, opt)}
+		}(i, target)
+	}
+
+	res := FanOutResult[T]{Outcomes: make([]Outcome[T], len(targets))}
+	done := make([]bool, len(targets))
+	failed := 0
+	for n := 0; n < len(targets); n++ {
+		r := <-ch
+		res.Outcomes[r.i] = r.o
+		done[r.i] = true
+		if r.o.OK {
+			res.Succeeded++
+		} else {
+			failed++
+		}
+		if opt.FirstK > 0 && res.Succeeded >= opt.FirstK {
+			break
+		}
+		if opt.Quorum > 0 && len(targets)-failed < opt.Quorum {
+			break // 남은 대상이 모두 성공해도 정족수 불가
+		}
+	}
+	stop()
+
+	// 조기 종료된 경우 나머지는 취소로 기록(고루틴은 ctx 취소로 정리됨)
+	for i, target := range targets {
+		if !done[i] {
+			res.Outcomes[i] = Outcome[T]{Target: target, Code: ErrTimeout, Message: "canceled", Canceled: true}
+		}
+	}
+	res.QuorumMet = opt.Quorum <= 0 || res.Succeeded >= opt.Quorum
+	return res
+}
+
+func runTarget[T any](ctx context.Context, target string, call func(ctx context.Context, target string) (T, error), opt FanOutOptions) Outcome[T] {
+	if d, ok := opt.TargetDeadlines[target]; ok && d > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	} else if opt.PerTarget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.PerTarget)
+		defer cancel()
+	}
+	ctx, stop := context.WithCancel(ctx)
+	defer stop() // 이긴 요청 외 hedge 요청 정리
+
+	type attempt struct {
+		v     T
+		err   error
+		hedge bool
+	}
+	maxHedges := opt.MaxHedges
+	if maxHedges <= 0 {
+		maxHedges = 1
+	}
+	start := time.Now()
+	ch := make(chan attempt, 1+maxHedges)
+	launch := func(hedge bool) {
+		go func() {
+			v, err := call(ctx, target)
+			ch <- attempt{v, err, hedge}
+		}()
+	}
+	launch(false)
+	launched, pending := 1, 1
+
+	var hedgeC <-chan time.Time
+	if opt.HedgeAfter > 0 {
+		t := time.NewTimer(opt.HedgeAfter)
+		defer t.Stop()
+		hedgeC = t.C
+	}
+
+	for {
+		select {
+		case a := <-ch:
+			pending--
+			if a.err == nil {
+				o := Outcome[T]{Target: target, OK: true, Value: a.v, Attempts: launched, Hedged: a.hedge}
+				return withLatency(o, start)
+			}
+			// hedge는 느린 대상용 — 진행 중인 요청이 모두 실패하면 재시도하지 않는다
+			if pending == 0 {
+				return withLatency(failedOutcome[T](target, a.err, launched), start)
+			}
+		case <-hedgeC:
+			hedgeC = nil
+			if launched <= maxHedges {
+				launch(true)
+				launched++
+				pending++
+				if launched <= maxHedges {
+					t := time.NewTimer(opt.HedgeAfter)
+					defer t.Stop()
+					hedgeC = t.C
+				}
+			}
+		case <-ctx.Done():
+			return withLatency(failedOutcome[T](target, ctx.Err(), launched), start)
+		}
+	}
+}
+
+func failedOutcome[T any](target string, err error, attempts int) Outcome[T] {
+	o := Outcome[T]{Target: target, Attempts: attempts, Message: err.Error()}
+	var ep *ErrorPayload
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		o.Code, o.TimedOut = ErrTimeout, true
+	case errors.Is(err, context.Canceled):
+		o.Code, o.Canceled = ErrTimeout, true
+	case errors.As(err, &ep):
+		o.Code, o.Message = ep.Code, ep.Message
+	default:
+		o.Code = ErrInternal
+	}
+	return o
+}
+
+func withLatency[T any](o Outcome[T], start time.Time) Outcome[T] {
+	o.Latency = time.Since(start)
+	o.LatencyMS = o.Latency.Milliseconds()
+	return o
+}
@@ -0,0 +1,178 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTargets: 대상별 지연과 오류. 지연 중 ctx가 끝나면 ctx 오류를 돌려주고 취소된 대상을 기록한다.
+type fakeTargets struct {
+	delay map[string]time.Duration
+	err   map[string]error
+	calls atomic.Int32
+
+	mu       sync.Mutex
+	canceled []string
+}
+
+func (f *fakeTargets) call(ctx context.Context, target string) (string, error) {
+	f.calls.Add(1)
+	select {
+	case <-time.After(f.delay[target]):
+	case <-ctx.Done():
+		f.mu.Lock()
+		f.canceled = append(f.canceled, target)
+		f.mu.Unlock()
+		return "", ctx.Err()
+	}
+	if err := f.err[target]; err != nil {
+		return "", err
+	}
+	return "v-" + target, nil
+}
+
+func (f *fakeTargets) wasCanceled(target string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.canceled {
+		if c == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScatterGatherQuorum(t *testing.T) {
+	boom := NewError(ErrUnavailable, "down")
+	tests := []struct {
+		name      string
+		err       map[string]error
+		quorum    int
+		met       bool
+		succeeded int
+		fastFail  bool // 정족수가 불가능해지면 느린 대상을 기다리지 않는다
+	}{
+		{"met", map[string]error{"c": boom}, 2, true, 2, false},
+		{"all succeed", nil, 3, true, 3, false},
+		{"missed early", map[string]error{"a": boom, "b": boom}, 2, false, 0, true},
+	}
+	for _, tt := range tests {
+		f := &fakeTargets{delay: map[string]time.Duration{"a": 0, "b": 0, "c": 200 * time.Millisecond}, err: tt.err}
+		if tt.fastFail {
+			f.delay["c"] = time.Minute
+		}
+		start := time.Now()
+		res := ScatterGather(context.Background(), []string{"a", "b", "c"}, f.call, FanOutOptions{Quorum: tt.quorum})
+		if res.QuorumMet != tt.met || res.Succeeded != tt.succeeded {
+			t.Errorf("%s: met = %v, succeeded = %d", tt.name, res.QuorumMet, res.Succeeded)
+		}
+		if tt.fastFail {
+			if d := time.Since(start); d > time.Second {
+				t.Errorf("%s: took %v", tt.name, d)
+			}
+			if c := res.Outcomes[2]; !c.Canceled || c.OK {
+				t.Errorf("%s: slow target outcome = %+v, want canceled", tt.name, c)
+			}
+		}
+		for i, o := range res.Outcomes {
+			if o.Target != []string{"a", "b", "c"}[i] {
+				t.Errorf("%s: outcomes out of target order: %+v", tt.name, res.Outcomes)
+			}
+		}
+	}
+}
+
+func TestScatterGatherFirstKCancelsLosers(t *testing.T) {
+	f := &fakeTargets{delay: map[string]time.Duration{"fast": 0, "slow": time.Minute}}
+	start := time.Now()
+	res := ScatterGather(context.Background(), []string{"slow", "fast"}, f.call, FanOutOptions{FirstK: 1, Quorum: 1})
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("FirstK did not return early: %v", d)
+	}
+	if !res.QuorumMet || res.Succeeded != 1 {
+		t.Errorf("met = %v, succeeded = %d", res.QuorumMet, res.Succeeded)
+	}
+	if got := res.Successes(); len(got) != 1 || got[0] != "v-fast" {
+		t.Errorf("successes = %v", got)
+	}
+	if fl := res.Failures(); len(fl) != 1 || fl[0].Target != "slow" || !fl[0].Canceled || fl[0].Code != ErrTimeout {
+		t.Errorf("failures = %+v", fl)
+	}
+	// 진 대상의 호출 ctx도 취소된다
+	deadline := time.Now().Add(time.Second)
+	for !f.wasCanceled("slow") {
+		if time.Now().After(deadline) {
+			t.Fatal("slow call was not canceled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestScatterGatherPerTargetDeadline(t *testing.T) {
+	f := &fakeTargets{delay: map[string]time.Duration{"a": 20 * time.Millisecond, "b": time.Minute, "c": 150 * time.Millisecond}}
+	opt := FanOutOptions{
+		PerTarget:       50 * time.Millisecond,
+		TargetDeadlines: map[string]time.Duration{"c": 500 * time.Millisecond}, // PerTarget보다 우선
+	}
+	res := ScatterGather(context.Background(), []string{"a", "b", "c"}, f.call, opt)
+	a, b, c := res.Outcomes[0], res.Outcomes[1], res.Outcomes[2]
+	if !a.OK || !c.OK {
+		t.Errorf("a = %+v, c = %+v", a, c)
+	}
+	if b.OK || !b.TimedOut || b.Code != ErrTimeout {
+		t.Errorf("b = %+v, want timed out", b)
+	}
+	if b.Latency > 500*time.Millisecond {
+		t.Errorf("b latency = %v, want about 50ms", b.Latency)
+	}
+
+	// 전체 마감은 대상별 마감보다 먼저 끝날 수 있다
+	res = ScatterGather(context.Background(), []string{"b"}, f.call, FanOutOptions{Deadline: 30 * time.Millisecond, PerTarget: time.Minute})
+	if o := res.Outcomes[0]; !o.TimedOut {
+		t.Errorf("overall deadline: %+v", o)
+	}
+}
+
+func TestScatterGatherHedge(t *testing.T) {
+	// 첫 요청은 느리고 hedge 요청은 빠른 대상
+	var n atomic.Int32
+	call := func(ctx context.Context, target string) (string, error) {
+		d := time.Minute
+		if n.Add(1) > 1 {
+			d = 0
+		}
+		select {
+		case <-time.After(d):
+			return "ok", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	start := time.Now()
+	res := ScatterGather(context.Background(), []string{"a"}, call, FanOutOptions{HedgeAfter: 50 * time.Millisecond})
+	o := res.Outcomes[0]
+	if !o.OK || !o.Hedged || o.Attempts != 2 {
+		t.Fatalf("outcome = %+v", o)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond || d > time.Second {
+		t.Errorf("hedge answered after %v, want shortly after 50ms", d)
+	}
+
+	// 응답이 HedgeAfter보다 빠르면 hedge하지 않고, 실패한 요청은 hedge로 재시도하지 않는다
+	f := &fakeTargets{delay: map[string]time.Duration{"ok": 0, "bad": 0}, err: map[string]error{"bad": errors.New("boom")}}
+	res = ScatterGather(context.Background(), []string{"ok", "bad"}, f.call, FanOutOptions{HedgeAfter: 50 * time.Millisecond})
+	if res.Outcomes[0].Attempts != 1 || res.Outcomes[0].Hedged {
+		t.Errorf("fast target = %+v", res.Outcomes[0])
+	}
+	if bad := res.Outcomes[1]; bad.OK || bad.Code != ErrInternal || bad.Attempts != 1 {
+		t.Errorf("failed target = %+v", bad)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if c := f.calls.Load(); c != 2 {
+		t.Errorf("calls = %d, want 2", c)
+	}
+}
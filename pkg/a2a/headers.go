@@ -5,4 +5,5 @@ const (
 	HeaderSignature   = "X-Agent-Signature"    // hmac-sha256:<hex>
 	HeaderTraceID     = "X-Agent-Trace-Id"     // 분산 추적
 	HeaderRequestTime = "X-Agent-Request-Time" // RFC3339 or epoch-sec (옵션)
+	HeaderDeadline    = "X-Agent-Deadline-Ms"  // 호출자에게 남은 시간(ms) — 홉마다 다시 계산
 )
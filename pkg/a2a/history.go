@@ -0,0 +1,101 @@
+package a2a
+
+import (
+	"context"
+	"time"
+)
+
+// ---- Task 감사 이력 -------------------------------------------------------------
+
+type HistoryAction string
+
+const (
+	HistoryCreated HistoryAction = "CREATED" // 접수
+	HistoryStatus  HistoryAction = "STATUS"  // 상태 전이
+	HistoryResult  HistoryAction = "RESULT"  // result/error 기록
+	HistoryEvent   HistoryAction = "EVENT"   // 수신 이벤트 반영
+	HistoryMessage HistoryAction = "MESSAGE" // INPUT_REQUIRED 답변 수신
+)
+
+// HistoryEntry: Task 변경 하나의 기록(누가, 언제, 어떤 추적 흐름에서, 왜)
+type HistoryEntry struct {
+	At      time.Time     `json:"at"`
+	Action  HistoryAction `json:"action"`
+	StepID  string        `json:"step_id,omitempty"` // 워크플로 단계 상태 전이일 때
+	From    TaskStatus    `json:"from,omitempty"`
+	To      TaskStatus    `json:"to,omitempty"`
+	Actor   string        `json:"actor,omitempty"` // 변경을 일으킨 에이전트 ID
+	TraceID string        `json:"trace_id,omitempty"`
+	Reason  string        `json:"reason,omitempty"`
+	EventID string        `json:"event_id,omitempty"` // Action=EVENT일 때
+}
+
+// Audit: 변경 주체 정보 — Record/SetStatus가 이력 항목에 채운다
+type Audit struct {
+	Actor   string
+	TraceID string
+}
+
+// Record: 이력 추가 + updated_at 갱신(이력 슬라이스는 저장소 사본과 공유하지 않도록 새로 만든다)
+func (t *Task) Record(a Audit, e HistoryEntry) {
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+	if e.Actor == "" {
+		e.Actor = a.Actor
+	}
+	if e.TraceID == "" {
+		e.TraceID = a.TraceID
+	}
+	t.History = append(append([]HistoryEntry(nil), t.History...), e)
+	t.UpdatedAt = e.At
+}
+
+// SetStatus: 상태를 바꾸고 전이를 기록. 종료 상태가 되면 completed_at 설정. 같은 상태면 아무것도 안 함.
+// 상태 기계(CanTransition)가 허용하지 않는 전이는 바꾸지 않고 CONFLICT.
+func (t *Task) SetStatus(to TaskStatus, a Audit, reason string) error {
+	if t.Status == to {
+		return nil
+	}
+	if !CanTransition(t.Status, to) {
+		return NewError(ErrConflict, "illegal transition "+string(t.Status)+" -> "+string(to))
+	}
+	from := t.Status
+	t.Status = to
+	t.Record(a, HistoryEntry{Action: HistoryStatus, From: from, To: to, Reason: reason})
+	if IsTerminal(to) && t.CompletedAt.IsZero() {
+		t.CompletedAt = t.UpdatedAt
+	}
+	return nil
+}
+
+// IsTerminal: 더 이상 전이가 없는 상태
+func IsTerminal(s TaskStatus) bool {
+	return s == StatusSucceeded || s == StatusFailed
+}
+
+// ---- 추적 ID / 대화 context 전달 ---------------------------------------------------------------
+
+type traceKey struct{}
+
+// WithTraceID / TraceID: 요청의 X-Agent-Trace-Id를 하위 호출까지 전달
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceKey{}, id)
+}
+
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+type contextIDKey struct{}
+
+// WithContextID / ContextID: 상위 task의 context_id — Client.CreateTask가 하위 task에 붙인다
+func WithContextID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextIDKey{}, id)
+}
+
+func ContextID(ctx context.Context) string {
+	id, _ := ctx.Value(contextIDKey{}).(string)
+	return id
+}
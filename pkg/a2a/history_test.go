@@ -0,0 +1,52 @@
+package a2a
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSetStatusFollowsStateMachine(t *testing.T) {
+	by := Audit{Actor: "agent.test", TraceID: "tr_1"}
+	tests := []struct {
+		from, to TaskStatus
+		ok       bool
+	}{
+		{StatusPending, StatusRunning, true},
+		{StatusPending, StatusSucceeded, true},
+		{StatusRunning, StatusInputRequired, true},
+		{StatusInputRequired, StatusRunning, true},
+		{StatusRunning, StatusFailed, true},
+		{StatusRunning, StatusPending, false},
+		{StatusSucceeded, StatusRunning, false},
+		{StatusFailed, StatusSucceeded, false},
+		{StatusSucceeded, StatusSucceeded, true}, // 같은 상태는 아무것도 안 함
+	}
+	for _, tt := range tests {
+		task := &Task{TaskID: "t_1", Status: tt.from}
+		err := task.SetStatus(tt.to, by, "test")
+		if tt.ok != (err == nil) {
+			t.Errorf("%s -> %s: err = %v", tt.from, tt.to, err)
+			continue
+		}
+		if !tt.ok {
+			var ep *ErrorPayload
+			if !errors.As(err, &ep) || ep.Code != ErrConflict || task.Status != tt.from || len(task.History) != 0 {
+				t.Errorf("%s -> %s: err = %v, task = %+v", tt.from, tt.to, err, task)
+			}
+			continue
+		}
+		if tt.from == tt.to {
+			if len(task.History) != 0 {
+				t.Errorf("%s -> %s: history = %+v", tt.from, tt.to, task.History)
+			}
+			continue
+		}
+		h := task.History
+		if task.Status != tt.to || len(h) != 1 || h[0].From != tt.from || h[0].To != tt.to || h[0].Actor != by.Actor || h[0].TraceID != by.TraceID {
+			t.Errorf("%s -> %s: task = %+v", tt.from, tt.to, task)
+		}
+		if IsTerminal(tt.to) != !task.CompletedAt.IsZero() {
+			t.Errorf("%s -> %s: completed_at = %v", tt.from, tt.to, task.CompletedAt)
+		}
+	}
+}
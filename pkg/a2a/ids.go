@@ -0,0 +1,13 @@
+package a2a
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// NewID: prefix + 랜덤 16바이트(hex)
+func NewID(prefix string) string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return prefix + hex.EncodeToString(b[:])
+}
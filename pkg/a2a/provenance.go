@@ -0,0 +1,32 @@
+package a2a
+
+import "slices"
+
+// ---- 해석 출처(INTERPRET 결과의 provenance) ---------------------------------------------
+
+// FieldSource: 필드 값이 어디서 왔는지
+type FieldSource string
+
+const (
+	SourceUser    FieldSource = "user"    // 구조화 입력/답으로 직접 준 값
+	SourceLLM     FieldSource = "llm"     // LLM 추론
+	SourceRule    FieldSource = "rule"    // 규칙 해석
+	SourceDefault FieldSource = "default" // 말하지 않아 채운 기본값
+)
+
+// Provenance: 필드 하나(점 경로 키)의 출처와 확신도(0~1)
+type Provenance struct {
+	Source     FieldSource `json:"source"`
+	Confidence float64     `json:"confidence"`
+}
+
+// LowConfidence: fields 중 확신도가 min 미만인 것(출처 정보가 없는 필드는 제외)
+func LowConfidence(prov map[string]Provenance, fields []string, min float64) []string {
+	var out []string
+	for _, f := range fields {
+		if p, ok := prov[f]; ok && p.Confidence < min && !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
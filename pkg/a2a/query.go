@@ -0,0 +1,127 @@
+package a2a
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// TaskFilter: GET /tasks 조회 조건 (0 값은 조건 없음)
+type TaskFilter struct {
+	Status         []TaskStatus
+	TaskType       string
+	AgentID        string
+	IdempotencyKey string
+	ContextID      string
+	CreatedFrom    time.Time // 이상
+	CreatedTo      time.Time // 미만
+	Limit          int
+	Cursor         string // 이전 페이지의 NextCursor
+}
+
+type TaskPage struct {
+	Tasks      []*Task `json:"tasks"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+func (f TaskFilter) Match(t *Task) bool {
+	if len(f.Status) > 0 && !slices.Contains(f.Status, t.Status) {
+		return false
+	}
+	if f.TaskType != "" && t.TaskType != f.TaskType {
+		return false
+	}
+	if f.AgentID != "" && t.AgentID != f.AgentID {
+		return false
+	}
+	if f.IdempotencyKey != "" && t.IdempotencyKey != f.IdempotencyKey {
+		return false
+	}
+	if f.ContextID != "" && t.ContextID != f.ContextID {
+		return false
+	}
+	if !f.CreatedFrom.IsZero() && t.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !t.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+func (f TaskFilter) limit() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultPageSize
+	case f.Limit > MaxPageSize:
+		return MaxPageSize
+	}
+	return f.Limit
+}
+
+// cursor: 정렬 키(created_at desc, task_id desc)상 마지막으로 반환한 위치
+type cursor struct {
+	CreatedAt time.Time `json:"c"`
+	TaskID    string    `json:"i"`
+}
+
+var ErrBadCursor = errors.New("invalid cursor")
+
+func (f TaskFilter) after() (*cursor, error) {
+	if f.Cursor == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return nil, ErrBadCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.TaskID == "" {
+		return nil, ErrBadCursor
+	}
+	return &c, nil
+}
+
+// before: 정렬 순서상 t가 커서 뒤(다음 페이지)에 오는가
+func (c *cursor) before(t *Task) bool {
+	if c == nil {
+		return true
+	}
+	return taskLess(&Task{TaskID: c.TaskID, CreatedAt: c.CreatedAt}, t)
+}
+
+func (c cursor) encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// taskLess: 최신 순, 같은 시각이면 task_id 역순 — 항상 전순서라 페이지가 흔들리지 않음
+func taskLess(a, b *Task) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.TaskID > b.TaskID
+}
+
+// page: 정렬 후 limit개 자르고 다음 커서 생성
+func page(ts []*Task, limit int) TaskPage {
+	sort.Slice(ts, func(i, j int) bool { return taskLess(ts[i], ts[j]) })
+	p := TaskPage{Tasks: ts}
+	if len(ts) > limit {
+		p.Tasks = ts[:limit]
+		last := p.Tasks[limit-1]
+		p.NextCursor = cursor{CreatedAt: last.CreatedAt, TaskID: last.TaskID}.encode()
+	}
+	if p.Tasks == nil {
+		p.Tasks = []*Task{}
+	}
+	return p
+}
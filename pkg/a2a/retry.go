@@ -0,0 +1,144 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RetryPolicy: 발신 호출 재시도 정책 (0 값 필드는 DefaultRetryPolicy 값 사용)
+type RetryPolicy struct {
+	MaxAttempts int           `yaml:"max_attempts" json:"max_attempts,omitempty"` // 첫 시도 포함, 1이면 재시도 없음
+	BaseDelay   time.Duration `yaml:"base_delay" json:"base_delay,omitempty"`     // 첫 재시도 대기(지수 증가)
+	MaxDelay    time.Duration `yaml:"max_delay" json:"max_delay,omitempty"`
+	Jitter      *float64      `yaml:"jitter" json:"jitter,omitempty"`     // 0..1, 대기 시간 중 무작위로 줄이는 비율(0이면 끔, nil이면 기본값)
+	RetryOn     []string      `yaml:"retry_on" json:"retry_on,omitempty"` // 재시도할 오류 코드
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      JitterOf(0.5),
+	RetryOn:     []string{ErrTimeout, ErrUnavailable, ErrInternal},
+}
+
+// JitterOf: RetryPolicy.Jitter 값(0도 명시적으로 지정할 수 있게 포인터)
+func JitterOf(j float64) *float64 { return &j }
+
+// NoRetry: 호출자가 자체 재시도 루프를 가진 경우(보상 작업 등)
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Merge: 비어 있는 필드를 def로 채운 정책
+func (p RetryPolicy) Merge(def RetryPolicy) RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = def.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+	if p.Jitter == nil {
+		p.Jitter = def.Jitter
+	}
+	if p.RetryOn == nil {
+		p.RetryOn = def.RetryOn
+	}
+	return p
+}
+
+// Delay: attempt번째(1부터) 실패 후 대기 시간 — base*2^(attempt-1), MaxDelay 상한, jitter만큼 무작위 감소
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 {
+		d = min(d, p.MaxDelay)
+	}
+	if p.Jitter == nil {
+		return d
+	}
+	if j := min(max(*p.Jitter, 0), 1); j > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * j * float64(d))
+	}
+	return d
+}
+
+// Retryable: err의 오류 코드가 RetryOn에 있는가. 호출자 ctx 취소/마감은 재시도하지 않는다.
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var ep *ErrorPayload
+	if !errors.As(err, &ep) {
+		return false
+	}
+	return slices.Contains(p.RetryOn, ep.Code)
+}
+
+// RetryBudget: 재시도 폭주 방지용 토큰 버킷. 요청마다 Ratio만큼 적립, 재시도마다 1 소모.
+// 예: Ratio 0.2 → 장기적으로 재시도는 요청의 20% 이내.
+type RetryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	Ratio  float64
+	Max    float64 // 적립 상한(버스트)
+}
+
+func NewRetryBudget(ratio, max float64) *RetryBudget {
+	return &RetryBudget{tokens: max, Ratio: ratio, Max: max}
+}
+
+// deposit: 첫 시도마다 호출
+func (b *RetryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.Ratio, b.Max)
+	b.mu.Unlock()
+}
+
+// withdraw: 재시도 가능하면 토큰 1 소모 후 true (nil 예산은 무제한)
+func (b *RetryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Retry: 정책에 따라 fn을 재시도. 마지막 오류를 반환.
+func Retry(ctx context.Context, p RetryPolicy, budget *RetryBudget, fn func(ctx context.Context, attempt int) error) error {
+	budget.deposit()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx, attempt)
+		if err == nil || attempt >= p.MaxAttempts || !p.Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		wait := p.Delay(attempt)
+		// 남은 마감 안에 대기+재시도할 여유가 없으면 포기
+		if dl, ok := ctx.Deadline(); ok && time.Until(dl) <= wait {
+			return err
+		}
+		if !budget.withdraw() {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
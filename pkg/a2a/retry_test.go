@@ -0,0 +1,203 @@
+package a2a
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: JitterOf(0)}
+	for attempt, want := range map[int]time.Duration{
+		1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond,
+		4: 800 * time.Millisecond, 5: time.Second, 50: time.Second,
+	} {
+		if got := p.Delay(attempt); got != want {
+			t.Errorf("Delay(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+// jitter는 대기를 줄이기만 한다: [d*(1-j), d], 범위 밖 값은 0..1로 자른다
+func TestRetryDelayJitter(t *testing.T) {
+	const d = 400 * time.Millisecond
+	cases := []struct {
+		jitter   *float64
+		min, max time.Duration
+	}{
+		{nil, d, d},
+		{JitterOf(0), d, d},
+		{JitterOf(-1), d, d},
+		{JitterOf(0.5), d / 2, d},
+		{JitterOf(1), 0, d},
+		{JitterOf(7), 0, d},
+	}
+	for _, c := range cases {
+		p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: c.jitter}
+		lo, hi := d, time.Duration(0)
+		for range 500 {
+			got := p.Delay(3)
+			lo, hi = min(lo, got), max(hi, got)
+		}
+		if lo < c.min || hi > c.max {
+			t.Errorf("jitter %v: delays in [%v, %v], want within [%v, %v]", deref(c.jitter), lo, hi, c.min, c.max)
+		}
+		if c.max > c.min && lo == hi {
+			t.Errorf("jitter %v: delay never varied (%v)", deref(c.jitter), lo)
+		}
+	}
+}
+
+func deref(j *float64) any {
+	if j == nil {
+		return nil
+	}
+	return *j
+}
+
+func TestRetryPolicyMerge(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, Jitter: JitterOf(0)}.Merge(DefaultRetryPolicy)
+	if p.MaxAttempts != 5 || p.BaseDelay != DefaultRetryPolicy.BaseDelay || p.MaxDelay != DefaultRetryPolicy.MaxDelay {
+		t.Errorf("merged = %+v", p)
+	}
+	if *p.Jitter != 0 {
+		t.Errorf("explicit jitter 0 overridden: %v", *p.Jitter)
+	}
+	if q := (RetryPolicy{}).Merge(DefaultRetryPolicy); *q.Jitter != 0.5 || len(q.RetryOn) != 3 {
+		t.Errorf("empty policy merged = %+v", q)
+	}
+	// 빈 목록은 "아무것도 재시도 안 함"으로 유지
+	if q := (RetryPolicy{RetryOn: []string{}}).Merge(DefaultRetryPolicy); len(q.RetryOn) != 0 {
+		t.Errorf("empty retry_on replaced: %v", q.RetryOn)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	p := DefaultRetryPolicy
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{NewError(ErrTimeout, "slow"), true},
+		{NewError(ErrUnavailable, "down"), true},
+		{NewError(ErrInternal, "boom"), true},
+		{fmt.Errorf("call: %w", NewError(ErrUnavailable, "down")), true},
+		{NewError(ErrValidationFailed, "bad"), false},
+		{NewError(ErrConflict, "dup"), false},
+		{NewError(ErrNotFound, "gone"), false},
+		{errors.New("plain"), false},
+		{context.Canceled, false},
+		{fmt.Errorf("%w: %w", context.Canceled, NewError(ErrUnavailable, "down")), false},
+	}
+	for _, c := range cases {
+		if got := p.Retryable(c.err); got != c.want {
+			t.Errorf("Retryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+	if (RetryPolicy{RetryOn: []string{ErrConflict}}).Retryable(NewError(ErrTimeout, "slow")) {
+		t.Error("custom retry_on still retries TIMEOUT")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, RetryOn: DefaultRetryPolicy.RetryOn}
+	cases := []struct {
+		name  string
+		errs  []error // attempt별 오류(끝나면 nil)
+		calls int
+		ok    bool
+	}{
+		{"first try", nil, 1, true},
+		{"recovers", []error{NewError(ErrUnavailable, ""), NewError(ErrTimeout, "")}, 3, true},
+		{"exhausted", []error{NewError(ErrUnavailable, ""), NewError(ErrUnavailable, ""), NewError(ErrUnavailable, ""), NewError(ErrUnavailable, ""), nil}, 4, false},
+		{"non-retryable", []error{NewError(ErrValidationFailed, ""), nil}, 1, false},
+		{"becomes non-retryable", []error{NewError(ErrInternal, ""), NewError(ErrConflict, ""), nil}, 2, false},
+	}
+	for _, c := range cases {
+		calls := 0
+		err := Retry(context.Background(), p, nil, func(_ context.Context, attempt int) error {
+			calls++
+			if attempt != calls {
+				t.Errorf("%s: attempt %d on call %d", c.name, attempt, calls)
+			}
+			if attempt <= len(c.errs) {
+				return c.errs[attempt-1]
+			}
+			return nil
+		})
+		if calls != c.calls || (err == nil) != c.ok {
+			t.Errorf("%s: calls = %d, err = %v; want %d calls, ok %v", c.name, calls, err, c.calls, c.ok)
+		}
+	}
+	calls := 0
+	Retry(context.Background(), NoRetry, nil, func(context.Context, int) error { calls++; return NewError(ErrUnavailable, "") })
+	if calls != 1 {
+		t.Errorf("NoRetry: calls = %d", calls)
+	}
+}
+
+// 남은 마감이 다음 대기보다 짧으면 재시도하지 않는다
+func TestRetryRespectsDeadline(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Second, RetryOn: []string{ErrUnavailable}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	calls, start := 0, time.Now()
+	Retry(ctx, p, nil, func(context.Context, int) error { calls++; return NewError(ErrUnavailable, "") })
+	if calls != 1 || time.Since(start) > 50*time.Millisecond {
+		t.Errorf("calls = %d after %v", calls, time.Since(start))
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	b := NewRetryBudget(0.5, 2)
+	for i := range 2 {
+		if !b.withdraw() {
+			t.Fatalf("withdraw %d: budget starts full", i)
+		}
+	}
+	if b.withdraw() {
+		t.Fatal("withdraw from empty budget")
+	}
+	b.deposit()
+	if b.withdraw() {
+		t.Fatal("half a token is not a retry")
+	}
+	b.deposit()
+	if !b.withdraw() {
+		t.Fatal("two deposits of 0.5 refill one retry")
+	}
+	for range 10 {
+		b.deposit()
+	}
+	if b.tokens != b.Max {
+		t.Errorf("tokens = %v, want capped at %v", b.tokens, b.Max)
+	}
+	var unlimited *RetryBudget
+	unlimited.deposit()
+	if !unlimited.withdraw() {
+		t.Error("nil budget refused a retry")
+	}
+}
+
+// 예산이 바닥나면 Retry는 첫 시도만 하고, 이후 요청이 적립해야 다시 재시도한다
+func TestRetryBudgetExhaustion(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Microsecond, MaxDelay: time.Microsecond, RetryOn: []string{ErrUnavailable}}
+	b := NewRetryBudget(0.5, 1)
+	call := func() int {
+		calls := 0
+		Retry(context.Background(), p, b, func(context.Context, int) error { calls++; return NewError(ErrUnavailable, "") })
+		return calls
+	}
+	if n := call(); n != 2 {
+		t.Errorf("full budget: calls = %d, want 2", n)
+	}
+	if n := call(); n != 1 {
+		t.Errorf("after 0.5 refill: calls = %d, want 1", n)
+	}
+	if n := call(); n != 2 {
+		t.Errorf("after 1.0 refill: calls = %d, want 2", n)
+	}
+}
@@ -0,0 +1,8 @@
+package a2a
+
+import "embed"
+
+// Schemas: 계약 JSON Schema 파일(schemas/*.json) — 검사/프롬프트/도구 선언이 모두 이 파일을 원본으로 쓴다
+//
+//go:embed schemas/*.json
+var Schemas embed.FS
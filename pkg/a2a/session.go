@@ -0,0 +1,151 @@
+package a2a
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ---- 대화 세션(context_id) -----------------------------------------------------
+
+// Session: context_id 하나의 대화 상태 — 이전 발화와 마지막 결과를 다음 요청에서 참조
+type Session struct {
+	ContextID   string                     `json:"context_id"`
+	CreatedAt   time.Time                  `json:"created_at"`
+	UpdatedAt   time.Time                  `json:"updated_at"`
+	TaskIDs     []string                   `json:"task_ids,omitempty"`     // 이 context의 최상위 task(생성 순)
+	Utterances  []string                   `json:"utterances,omitempty"`   // 사용자 발화(답변 포함)
+	LastRequest json.RawMessage            `json:"last_request,omitempty"` // 마지막으로 확정된 QuoteRequest
+	LastQuotes  json.RawMessage            `json:"last_quotes,omitempty"`  // 마지막 견적 목록
+	State       map[string]json.RawMessage `json:"state,omitempty"`        // task_type → 마지막 결과
+}
+
+// SessionStore: 세션 저장소. Update는 없으면 새로 만든다.
+type SessionStore interface {
+	Get(id string) (*Session, bool)
+	Update(id string, fn func(s *Session)) (*Session, error)
+}
+
+// clone: 저장소 밖으로 내보내는 사본(슬라이스/맵 공유 안 함)
+func (s *Session) clone() *Session {
+	cp := *s
+	cp.TaskIDs = append([]string(nil), s.TaskIDs...)
+	cp.Utterances = append([]string(nil), s.Utterances...)
+	if s.State != nil {
+		cp.State = make(map[string]json.RawMessage, len(s.State))
+		for k, v := range s.State {
+			cp.State[k] = v
+		}
+	}
+	return &cp
+}
+
+func newSession(id string) *Session {
+	now := time.Now().UTC()
+	return &Session{ContextID: id, CreatedAt: now, UpdatedAt: now}
+}
+
+type MemorySessionStore struct {
+	mu sync.Mutex
+	m  map[string]*Session
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{m: map[string]*Session{}}
+}
+
+func (s *MemorySessionStore) Get(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, ok := s.m[id]
+	if !ok {
+		return nil, false
+	}
+	return ss.clone(), true
+}
+
+func (s *MemorySessionStore) Update(id string, fn func(s *Session)) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.m[id]
+	if !ok {
+		cur = newSession(id)
+	}
+	cp := cur.clone()
+	fn(cp)
+	cp.UpdatedAt = time.Now().UTC()
+	s.m[id] = cp
+	return cp.clone(), nil
+}
+
+// FileSessionStore: dir/<context_id>.json (FileStore와 같은 방식)
+type FileSessionStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func NewFileSessionStore(dir string) (*FileSessionStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSessionStore{dir: dir}, nil
+}
+
+func (s *FileSessionStore) path(id string) (string, error) {
+	if err := checkID(id); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func (s *FileSessionStore) Get(id string) (*Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, err := s.read(id)
+	return ss, err == nil
+}
+
+func (s *FileSessionStore) Update(id string, fn func(s *Session)) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	ss, err := s.read(id)
+	if errors.Is(err, os.ErrNotExist) {
+		ss, err = newSession(id), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	fn(ss)
+	ss.UpdatedAt = time.Now().UTC()
+	b, err := json.Marshal(ss)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(p+".tmp", b, 0o644); err != nil {
+		return nil, err
+	}
+	return ss, os.Rename(p+".tmp", p)
+}
+
+func (s *FileSessionStore) read(id string) (*Session, error) {
+	p, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var ss Session
+	if err := json.Unmarshal(b, &ss); err != nil {
+		return nil, err
+	}
+	return &ss, nil
+}
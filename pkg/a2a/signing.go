@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
+	"net/http"
+	"time"
 )
 
 // Canonical string을 HMAC-SHA256으로 서명/검증
@@ -32,3 +34,22 @@ func CanonicalString(method, path, rawQuery, body string, timestamp string) stri
 	h := sha256.Sum256([]byte(body))
 	return method + "\n" + path + "\n" + rawQuery + "\n" + hex.EncodeToString(h[:]) + "\n" + timestamp
 }
+
+// Signer: 발신 요청에 A2A 헤더와 서명을 붙인다(nil이면 아무것도 안 함)
+type Signer struct {
+	AgentID string
+	Secret  []byte
+}
+
+// Sign: HMACMiddleware가 검증하는 canonical string으로 서명. body는 요청 본문 그대로.
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	if s == nil || len(s.Secret) == 0 {
+		return nil
+	}
+	ts := time.Now().UTC().Format(time.RFC3339)
+	canon := CanonicalString(req.Method, req.URL.Path, req.URL.RawQuery, string(body), ts)
+	req.Header.Set(HeaderAgentID, s.AgentID)
+	req.Header.Set(HeaderRequestTime, ts)
+	req.Header.Set(HeaderSignature, "hmac-sha256:"+MakeHMACSHA256(s.Secret, []byte(canon)))
+	return nil
+}
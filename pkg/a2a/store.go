@@ -0,0 +1,246 @@
+package a2a
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var ErrTaskNotFound = errors.New("task not found")
+
+// TaskStore: Task 저장소. 반환되는 *Task는 복사본이므로 수정 후 Put/Update로 반영한다.
+type TaskStore interface {
+	Get(id string) (*Task, bool)
+	Put(t *Task) error
+	// Update: id의 Task를 잠근 상태에서 fn으로 수정 후 저장(fn이 오류면 저장 안 함)
+	Update(id string, fn func(t *Task) error) (*Task, error)
+	// List: 필터 + 커서 페이지네이션 (created_at desc, task_id desc)
+	List(f TaskFilter) (TaskPage, error)
+}
+
+// ---- 메모리 저장소 -------------------------------------------------------------
+
+type MemoryStore struct {
+	mu sync.Mutex
+	m  map[string]*Task
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{m: map[string]*Task{}}
+}
+
+func (s *MemoryStore) Get(id string) (*Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.m[id]
+	if !ok {
+		return nil, false
+	}
+	cp := *t
+	return &cp, true
+}
+
+func (s *MemoryStore) Put(t *Task) error {
+	cp := *t
+	s.mu.Lock()
+	s.m[t.TaskID] = &cp
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) Update(id string, fn func(t *Task) error) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.m[id]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	cp := *cur
+	if err := fn(&cp); err != nil {
+		return nil, err
+	}
+	s.m[id] = &cp
+	out := cp
+	return &out, nil
+}
+
+func (s *MemoryStore) List(f TaskFilter) (TaskPage, error) {
+	after, err := f.after()
+	if err != nil {
+		return TaskPage{}, err
+	}
+	s.mu.Lock()
+	var out []*Task
+	for _, t := range s.m {
+		if f.Match(t) && after.before(t) {
+			cp := *t
+			out = append(out, &cp)
+		}
+	}
+	s.mu.Unlock()
+	return page(out, f.limit()), nil
+}
+
+// ---- 파일 저장소(재시작 후에도 유지) ---------------------------------------------
+
+// ErrInvalidID: 파일 이름으로 쓸 수 없는 id(경로 구분자, ".", "..")
+var ErrInvalidID = errors.New("invalid id")
+
+// checkID: 파일 저장소의 id는 파일 이름 하나 — 경로가 섞이면 잘라 쓰지 않고 거절
+func checkID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, "/\\\x00") {
+		return fmt.Errorf("%w %q", ErrInvalidID, id)
+	}
+	return nil
+}
+
+// FileStore: dir/<task_id>.json 에 Task 하나씩 저장
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+	// List 필터용 요약(필터/정렬 필드만) — 처음 List 때 디렉터리에서 채우고 write가 갱신.
+	// 필터와 페이지는 요약으로 정하고 파일은 돌려줄 페이지만 읽는다.
+	index map[string]Task
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) (string, error) {
+	if err := checkID(id); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+func (s *FileStore) Get(id string) (*Task, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.read(id)
+	return t, err == nil
+}
+
+func (s *FileStore) Put(t *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.write(t)
+}
+
+func (s *FileStore) Update(id string, fn func(t *Task) error) (*Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.read(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(t); err != nil {
+		return nil, err
+	}
+	return t, s.write(t)
+}
+
+func (s *FileStore) List(f TaskFilter) (TaskPage, error) {
+	after, err := f.after()
+	if err != nil {
+		return TaskPage{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index == nil {
+		if err := s.loadIndex(); err != nil {
+			return TaskPage{}, err
+		}
+	}
+	var out []*Task
+	for _, sum := range s.index {
+		if f.Match(&sum) && after.before(&sum) {
+			out = append(out, &sum)
+		}
+	}
+	p := page(out, f.limit())
+	full := p.Tasks[:0]
+	for _, sum := range p.Tasks {
+		if t, err := s.read(sum.TaskID); err == nil {
+			full = append(full, t)
+		}
+	}
+	p.Tasks = full
+	return p, nil
+}
+
+// loadIndex: 디렉터리의 task 파일을 한 번 읽어 요약을 만든다(mu 잡은 채로)
+func (s *FileStore) loadIndex() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	index := map[string]Task{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		if t, err := s.read(strings.TrimSuffix(name, ".json")); err == nil {
+			index[t.TaskID] = summary(t)
+		}
+	}
+	s.index = index
+	return nil
+}
+
+// summary: TaskFilter.Match와 정렬에 쓰는 필드만
+func summary(t *Task) Task {
+	return Task{
+		TaskID: t.TaskID, TaskType: t.TaskType, Status: t.Status, AgentID: t.AgentID,
+		IdempotencyKey: t.IdempotencyKey, ContextID: t.ContextID, CreatedAt: t.CreatedAt,
+	}
+}
+
+func (s *FileStore) read(id string) (*Task, error) {
+	p, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Task
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// 임시 파일에 쓰고 rename — 중간에 죽어도 깨진 JSON이 남지 않게
+func (s *FileStore) write(t *Task) error {
+	p, err := s.path(t.TaskID)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(p+".tmp", b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(p+".tmp", p); err != nil {
+		return err
+	}
+	if s.index != nil {
+		s.index[t.TaskID] = summary(t)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package a2a
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func stores(t *testing.T) map[string]func() TaskStore {
+	return map[string]func() TaskStore{
+		"memory": func() TaskStore { return NewMemoryStore() },
+		"file": func() TaskStore {
+			s, err := NewFileStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return s
+		},
+	}
+}
+
+func ids(ts []*Task) []string {
+	out := make([]string, len(ts))
+	for i, t := range ts {
+		out[i] = t.TaskID
+	}
+	return out
+}
+
+// 끝까지 페이지를 따라가며 task_id를 모은다
+func listAll(t *testing.T, s TaskStore, f TaskFilter) []string {
+	t.Helper()
+	var out []string
+	for {
+		p, err := s.List(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, ids(p.Tasks)...)
+		if p.NextCursor == "" {
+			return out
+		}
+		f.Cursor = p.NextCursor
+	}
+}
+
+func TestListOrder(t *testing.T) {
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			// 같은 created_at은 task_id 역순
+			for _, tk := range []*Task{
+				{TaskID: "t_a", CreatedAt: t0},
+				{TaskID: "t_c", CreatedAt: t0},
+				{TaskID: "t_b", CreatedAt: t0},
+				{TaskID: "t_z", CreatedAt: t0.Add(-time.Second)},
+				{TaskID: "t_0", CreatedAt: t0.Add(time.Second)},
+			} {
+				if err := s.Put(tk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := []string{"t_0", "t_c", "t_b", "t_a", "t_z"}
+			for _, limit := range []int{1, 2, 5, 0} {
+				if got := listAll(t, s, TaskFilter{Limit: limit}); !slices.Equal(got, want) {
+					t.Errorf("limit %d: %v, want %v", limit, got, want)
+				}
+			}
+		})
+	}
+}
+
+// 페이지 사이에 새 task가 들어와도 이미 본 것은 반복되지 않고 남은 것은 빠지지 않는다
+func TestListCursorStable(t *testing.T) {
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			for i := range 6 {
+				s.Put(&Task{TaskID: fmt.Sprintf("t_%d", i), CreatedAt: t0.Add(time.Duration(i) * time.Second)})
+			}
+			p, err := s.List(TaskFilter{Limit: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ids(p.Tasks)
+			// 더 최신(커서 앞), 커서와 같은 시각(앞/뒤), 더 오래된 task
+			s.Put(&Task{TaskID: "t_new", CreatedAt: t0.Add(time.Minute)})
+			s.Put(&Task{TaskID: "t_4z", CreatedAt: t0.Add(4 * time.Second)})
+			s.Put(&Task{TaskID: "t_4", CreatedAt: t0.Add(4 * time.Second)}) // 같은 키 덮어쓰기
+			s.Put(&Task{TaskID: "t_3a", CreatedAt: t0.Add(4 * time.Second)})
+			s.Put(&Task{TaskID: "t_old", CreatedAt: t0.Add(-time.Minute)})
+			got = append(got, listAll(t, s, TaskFilter{Limit: 2, Cursor: p.NextCursor})...)
+			want := []string{"t_5", "t_4", "t_3a", "t_3", "t_2", "t_1", "t_0", "t_old"}
+			if !slices.Equal(got, want) {
+				t.Errorf("pages = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	seed := []*Task{
+		{TaskID: "t_1", TaskType: "QUOTE", Status: StatusSucceeded, AgentID: "agent-a", IdempotencyKey: "k1", ContextID: "ctx_1", CreatedAt: t0},
+		{TaskID: "t_2", TaskType: "QUOTE", Status: StatusFailed, AgentID: "agent-b", ContextID: "ctx_1", CreatedAt: t0.Add(time.Second)},
+		{TaskID: "t_3", TaskType: "SHIP", Status: StatusRunning, AgentID: "agent-a", IdempotencyKey: "k2", ContextID: "ctx_2", CreatedAt: t0.Add(2 * time.Second)},
+		{TaskID: "t_4", TaskType: "SHIP", Status: StatusPending, AgentID: "agent-b", CreatedAt: t0.Add(3 * time.Second)},
+	}
+	cases := []struct {
+		name string
+		f    TaskFilter
+		want []string
+	}{
+		{"none", TaskFilter{}, []string{"t_4", "t_3", "t_2", "t_1"}},
+		{"status", TaskFilter{Status: []TaskStatus{StatusSucceeded, StatusPending}}, []string{"t_4", "t_1"}},
+		{"task_type", TaskFilter{TaskType: "SHIP"}, []string{"t_4", "t_3"}},
+		{"agent_id", TaskFilter{AgentID: "agent-a"}, []string{"t_3", "t_1"}},
+		{"idempotency_key", TaskFilter{IdempotencyKey: "k2"}, []string{"t_3"}},
+		{"context_id", TaskFilter{ContextID: "ctx_1"}, []string{"t_2", "t_1"}},
+		{"created range", TaskFilter{CreatedFrom: t0.Add(time.Second), CreatedTo: t0.Add(3 * time.Second)}, []string{"t_3", "t_2"}},
+		{"combined", TaskFilter{TaskType: "QUOTE", AgentID: "agent-b"}, []string{"t_2"}},
+		{"no match", TaskFilter{IdempotencyKey: "k9"}, nil},
+	}
+	for name, open := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := open()
+			for _, tk := range seed {
+				s.Put(tk)
+			}
+			// 상태가 바뀐 뒤에도 필터는 새 값을 본다
+			if _, err := s.Update("t_4", func(tk *Task) error { tk.Status = StatusRunning; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Update("t_4", func(tk *Task) error { tk.Status = StatusPending; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range cases {
+				c.f.Limit = 1
+				if got := listAll(t, s, c.f); !slices.Equal(got, c.want) {
+					t.Errorf("%s: %v, want %v", c.name, got, c.want)
+				}
+			}
+			if _, err := s.List(TaskFilter{Cursor: "not a cursor"}); !errors.Is(err, ErrBadCursor) {
+				t.Errorf("bad cursor: err = %v", err)
+			}
+		})
+	}
+}
+
+// 파일 저장소는 필터한 요약으로 페이지를 정하고, 재시작 후에도 같은 결과를 낸다
+func TestFileStoreListReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := NewFileStore(dir)
+	s.Put(&Task{TaskID: "t_1", TaskType: "QUOTE", Status: StatusSucceeded, Result: []byte(`{"n":1}`), CreatedAt: t0})
+	s.Put(&Task{TaskID: "t_2", TaskType: "SHIP", CreatedAt: t0.Add(time.Second)})
+	os.WriteFile(filepath.Join(dir, "t_3.json.tmp"), []byte("{"), 0o644) // 쓰다 만 파일은 무시
+
+	s, _ = NewFileStore(dir)
+	p, err := s.List(TaskFilter{TaskType: "QUOTE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Tasks) != 1 || p.Tasks[0].TaskID != "t_1" || string(p.Tasks[0].Result) != `{"n":1}` {
+		t.Fatalf("tasks = %+v", p.Tasks)
+	}
+	s.Put(&Task{TaskID: "t_4", TaskType: "QUOTE", CreatedAt: t0.Add(2 * time.Second)})
+	if got := listAll(t, s, TaskFilter{TaskType: "QUOTE"}); !slices.Equal(got, []string{"t_4", "t_1"}) {
+		t.Errorf("after put: %v", got)
+	}
+}
+
+func TestFileStoresRejectPathIDs(t *testing.T) {
+	dir := t.TempDir()
+	ts, _ := NewFileStore(filepath.Join(dir, "tasks"))
+	ss, _ := NewFileSessionStore(filepath.Join(dir, "sessions"))
+	for _, id := range []string{"", ".", "..", "../x", "a/b", `a\b`, "/etc/passwd", "a\x00b"} {
+		if err := ts.Put(&Task{TaskID: id}); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("task Put(%q): err = %v", id, err)
+		}
+		if _, ok := ts.Get(id); ok {
+			t.Errorf("task Get(%q) ok", id)
+		}
+		if _, err := ts.Update(id, func(*Task) error { return nil }); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("task Update(%q): err = %v", id, err)
+		}
+		if _, err := ss.Update(id, func(*Session) {}); !errors.Is(err, ErrInvalidID) {
+			t.Errorf("session Update(%q): err = %v", id, err)
+		}
+		if _, ok := ss.Get(id); ok {
+			t.Errorf("session Get(%q) ok", id)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Errorf("wrote outside the store dirs: %v", entries)
+	}
+	if err := ts.Put(&Task{TaskID: "t_ok"}); err != nil {
+		t.Error(err)
+	}
+	if _, err := ss.Update("ctx_ok", func(*Session) {}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,62 @@
+package a2a
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ---- 배송 추적(TRACK) ----------------------------------------------------------------
+
+// ShipmentStatus: 배송 생애주기
+type ShipmentStatus string
+
+const (
+	ShipmentLabelCreated   ShipmentStatus = "LABEL_CREATED"
+	ShipmentPickedUp       ShipmentStatus = "PICKED_UP"
+	ShipmentInTransit      ShipmentStatus = "IN_TRANSIT"
+	ShipmentOutForDelivery ShipmentStatus = "OUT_FOR_DELIVERY"
+	ShipmentDelivered      ShipmentStatus = "DELIVERED"
+	ShipmentException      ShipmentStatus = "EXCEPTION" // 주소 불명, 통관 보류 등
+)
+
+// 허용 전이 — IN_TRANSIT은 중간 경유지마다 반복될 수 있고, EXCEPTION은 해소되면 다시 IN_TRANSIT
+var shipmentTransitions = map[ShipmentStatus][]ShipmentStatus{
+	ShipmentLabelCreated:   {ShipmentPickedUp, ShipmentException},
+	ShipmentPickedUp:       {ShipmentInTransit, ShipmentException},
+	ShipmentInTransit:      {ShipmentInTransit, ShipmentOutForDelivery, ShipmentException},
+	ShipmentOutForDelivery: {ShipmentDelivered, ShipmentException},
+	ShipmentException:      {ShipmentInTransit},
+}
+
+// CanAdvance: from 다음에 to가 올 수 있는지. from이 비어 있으면(첫 체크포인트) LABEL_CREATED만.
+func CanAdvance(from, to ShipmentStatus) bool {
+	if from == "" {
+		return to == ShipmentLabelCreated
+	}
+	for _, s := range shipmentTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Checkpoint: 추적 이력 한 줄
+type Checkpoint struct {
+	Status   ShipmentStatus `json:"status"`
+	At       time.Time      `json:"at"`
+	Location string         `json:"location,omitempty"`
+	Message  string         `json:"message,omitempty"`
+}
+
+// TrackingPayload: TRACKING_UPDATE 이벤트 payload(새 체크포인트 하나)
+type TrackingPayload struct {
+	TrackingID string     `json:"tracking_id"`
+	Carrier    string     `json:"carrier,omitempty"`
+	Checkpoint Checkpoint `json:"checkpoint"`
+}
+
+func NewTrackingEvent(taskID string, p TrackingPayload) Event {
+	b, _ := json.Marshal(p)
+	return Event{Event: EventTrackingUpdate, TaskID: taskID, Payload: b}
+}
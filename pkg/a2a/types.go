@@ -1,6 +1,9 @@
 package a2a
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 const ContractVersion = "1.0"
 
@@ -13,6 +16,8 @@ const (
 	StatusRunning   TaskStatus = "RUNNING"
 	StatusSucceeded TaskStatus = "SUCCEEDED"
 	StatusFailed    TaskStatus = "FAILED"
+	// 추가 입력을 기다리는 중 — POST /tasks/{id}/messages로 답하면 재개
+	StatusInputRequired TaskStatus = "INPUT_REQUIRED"
 )
 
 // CreateTask: 다른 에이전트에게 작업을 위임할 때 사용하는 표준 입력
@@ -21,17 +26,73 @@ type CreateTask struct {
 	Input          json.RawMessage `json:"input"`                     // 도메인별 입력(JSON blob)
 	ReplyURL       string          `json:"reply_url,omitempty"`       // 콜백 받을 URL(옵션)
 	IdempotencyKey string          `json:"idempotency_key,omitempty"` // 멱등 처리용
+	ContextID      string          `json:"context_id,omitempty"`      // 같은 대화(세션)의 task 묶음 — 하위 task로 전파
 	Meta           map[string]any  `json:"meta,omitempty"`            // 추가 컨텍스트(옵션)
 }
 
 // Task: 작업의 현재 상태/결과/오류를 나타내는 표준 출력
 type Task struct {
-	TaskID string          `json:"task_id"`
-	Status TaskStatus      `json:"status"`
-	Result json.RawMessage `json:"result,omitempty"` // 도메인별 결과(JSON blob)
-	Error  *ErrorPayload   `json:"error,omitempty"`
+	TaskID string     `json:"task_id"`
+	Status TaskStatus `json:"status"`
+	// 조회/필터용 메타데이터(CreateTask 수신 시 채움)
+	TaskType       string    `json:"task_type,omitempty"`
+	AgentID        string    `json:"agent_id,omitempty"` // 호출 주체(X-Agent-Id)
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	ContextID      string    `json:"context_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at,omitzero"`
+	UpdatedAt      time.Time `json:"updated_at,omitzero"`
+	CompletedAt    time.Time `json:"completed_at,omitzero"` // SUCCEEDED/FAILED 도달 시각
+
+	Result    json.RawMessage `json:"result,omitempty"`    // 도메인별 결과(JSON blob)
+	Artifacts []Artifact      `json:"artifacts,omitempty"` // 첨부 파일(라벨 등)
+	Error     *ErrorPayload   `json:"error,omitempty"`
+	Steps     []StepRun       `json:"steps,omitempty"`    // 워크플로로 실행된 경우 단계별 기록
+	Saga      []SagaEntry     `json:"saga_log,omitempty"` // 실패 시 실행된 보상 작업 기록
+	Progress  []ProgressEntry `json:"progress,omitempty"` // TASK_PROGRESS 이력
+	Tracking  []Checkpoint    `json:"tracking,omitempty"` // TRACKING_UPDATE로 받은 체크포인트(CanAdvance 순서)
+	// INPUT_REQUIRED일 때 필요한 입력과 질문
+	InputRequired *InputRequest `json:"input_required,omitempty"`
+	// 원 요청(INPUT_REQUIRED 후 재개할 때 사용)
+	Request *CreateTask    `json:"request,omitempty"`
+	History []HistoryEntry `json:"history,omitempty"` // 상태/결과/이벤트 변경 감사 이력
+}
+
+// StepRun: 오케스트레이터가 실행한 워크플로 단계 하나의 기록
+type StepRun struct {
+	StepID     string          `json:"step_id"`
+	TaskType   string          `json:"task_type"`
+	Agent      string          `json:"agent,omitempty"`
+	Status     TaskStatus      `json:"status"`          // + SKIPPED | CANCELED (단계 전용)
+	Input      json.RawMessage `json:"input,omitempty"` // 보낸 입력 — 재개 후 실패해도 보상 매핑에 쓴다
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      *ErrorPayload   `json:"error,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	// 단계가 INPUT_REQUIRED로 멈췄을 때 답을 전달할 하위 task
+	RemoteURL    string `json:"remote_url,omitempty"`
+	RemoteTaskID string `json:"remote_task_id,omitempty"`
 }
 
+// SagaEntry: 보상(compensation) 시도 하나의 기록
+type SagaEntry struct {
+	StepID   string          `json:"step_id"`   // 보상 대상 단계
+	TaskType string          `json:"task_type"` // 보상 task_type (e.g. VOID_SHIPMENT)
+	Agent    string          `json:"agent,omitempty"`
+	Attempt  int             `json:"attempt"`
+	Status   SagaStatus      `json:"status"`
+	Result   json.RawMessage `json:"result,omitempty"`
+	Error    *ErrorPayload   `json:"error,omitempty"`
+	At       time.Time       `json:"at"`
+}
+
+type SagaStatus string
+
+const (
+	SagaCompensated SagaStatus = "COMPENSATED"
+	SagaRetrying    SagaStatus = "RETRYING"  // 실패, 재시도 예정
+	SagaEscalated   SagaStatus = "ESCALATED" // 재시도 소진 — 수동 처리 필요
+)
+
 // ---- Agent discovery ---------------------------------------------------------
 
 type AgentMeta struct {
@@ -56,8 +117,67 @@ type AuthSpec struct {
 
 // ---- Task events (async callbacks) ------------------------------------------
 
+type EventType string
+
+const (
+	EventTaskProgress   EventType = "TASK_PROGRESS"       // payload: ProgressPayload
+	EventTaskCompleted  EventType = "TASK_COMPLETED"      // payload: 결과(JSON blob) 그대로
+	EventTaskFailed     EventType = "TASK_FAILED"         // payload: FailedPayload
+	EventInputRequired  EventType = "TASK_INPUT_REQUIRED" // payload: InputRequest
+	EventTrackingUpdate EventType = "TRACKING_UPDATE"     // payload: TrackingPayload (TRACK 구독)
+)
+
 type Event struct {
-	Event   string          `json:"event"` // e.g. TASK_COMPLETED | TASK_FAILED | TASK_PROGRESS
-	TaskID  string          `json:"task_id"`
-	Payload json.RawMessage `json:"payload,omitempty"` // 결과/중간상태
+	EventID   string          `json:"event_id,omitempty"` // 중복 제거용(발신 측이 부여)
+	Seq       int64           `json:"seq,omitempty"`      // 작업별 1부터 단조 증가(0이면 순서 없음)
+	Timestamp time.Time       `json:"timestamp,omitzero"`
+	Event     EventType       `json:"event"` // e.g. TASK_COMPLETED | TASK_FAILED | TASK_PROGRESS
+	TaskID    string          `json:"task_id"`
+	Payload   json.RawMessage `json:"payload,omitempty"` // 결과/중간상태
+}
+
+type ProgressPayload struct {
+	Percent int    `json:"percent"` // 0~100
+	Message string `json:"message,omitempty"`
+}
+
+type FailedPayload struct {
+	Error *ErrorPayload `json:"error"`
+}
+
+// ProgressEntry: 수신한 TASK_PROGRESS 기록
+type ProgressEntry struct {
+	ProgressPayload
+	At time.Time `json:"at"`
+}
+
+// ---- 추가 입력(multi-turn) ---------------------------------------------------
+
+type FieldReason string
+
+const (
+	FieldMissing       FieldReason = "MISSING"
+	FieldAmbiguous     FieldReason = "AMBIGUOUS"
+	FieldLowConfidence FieldReason = "LOW_CONFIDENCE" // 해석은 했지만 확신이 낮음 — Candidates[0]이 맞는지 확인
+)
+
+// InputRequest: INPUT_REQUIRED task가 호출자에게 요청하는 입력
+type InputRequest struct {
+	Fields    []FieldRequest `json:"fields"`
+	Questions []string       `json:"questions,omitempty"` // 사용자에게 그대로 보여줄 질문
+}
+
+// FieldRequest: 빠졌거나 애매한 필드 하나
+type FieldRequest struct {
+	Field      string      `json:"field"` // 점 경로, e.g. to.country | parcel.weight_kg
+	Reason     FieldReason `json:"reason"`
+	Candidates []string    `json:"candidates,omitempty"` // AMBIGUOUS일 때 후보, LOW_CONFIDENCE면 해석한 값
+	Question   string      `json:"question,omitempty"`
+}
+
+// Message: POST /tasks/{id}/messages 본문 — 자연어 답(text) 또는 필드 경로 → 값(data)
+type Message struct {
+	Role string         `json:"role,omitempty"` // 기본 user
+	Text string         `json:"text,omitempty"`
+	Data map[string]any `json:"data,omitempty"` // e.g. {"to.country": "US"}
 }
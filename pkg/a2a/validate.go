@@ -12,12 +12,25 @@ func ValidateCreateTask(ct *CreateTask) error {
 	return nil
 }
 
+// ValidateMessage: text나 data 중 하나는 있어야 함
+func ValidateMessage(m *Message) error {
+	if m.Text == "" && len(m.Data) == 0 {
+		return errors.New("text or data is required")
+	}
+	if m.Role == "" {
+		m.Role = "user"
+	}
+	return nil
+}
+
 func CanTransition(from, to TaskStatus) bool {
 	switch from {
 	case StatusPending:
-		return to == StatusRunning || to == StatusSucceeded || to == StatusFailed
+		return to == StatusRunning || to == StatusSucceeded || to == StatusFailed || to == StatusInputRequired
 	case StatusRunning:
-		return to == StatusSucceeded || to == StatusFailed
+		return to == StatusSucceeded || to == StatusFailed || to == StatusInputRequired
+	case StatusInputRequired:
+		return to == StatusRunning || to == StatusSucceeded || to == StatusFailed
 	case StatusSucceeded, StatusFailed:
 		return false
 	default:
@@ -0,0 +1,29 @@
+package label
+
+import (
+	"fmt"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+)
+
+// code128Image: 트래킹 번호 바코드, 모듈 폭을 정수 배로 맞춰 maxW 안에 넣는다
+func code128Image(data string, maxW, h int) (barcode.Barcode, error) {
+	bc, err := code128.Encode(data)
+	if err != nil {
+		return nil, fmt.Errorf("code128: %w", err)
+	}
+	k := max(1, maxW/bc.Bounds().Dx())
+	return barcode.Scale(bc, bc.Bounds().Dx()*k, h)
+}
+
+// qrImage: QR 코드(오류 정정 M), size 이하의 정수 배 크기
+func qrImage(data string, size int) (barcode.Barcode, error) {
+	bc, err := qr.Encode(data, qr.M, qr.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("qr: %w", err)
+	}
+	k := max(1, size/bc.Bounds().Dx())
+	return barcode.Scale(bc, bc.Bounds().Dx()*k, bc.Bounds().Dy()*k)
+}
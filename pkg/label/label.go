@@ -0,0 +1,147 @@
+// Package label: 캐리어 에이전트의 배송 라벨(PDF/PNG) 생성 — 순수 Go(go-pdf/fpdf, boombuler/barcode)
+package label
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Label: 라벨 한 장에 찍히는 정보
+type Label struct {
+	Carrier    string
+	Service    string // 서비스 등급(EXPRESS/ECONOMY...)
+	TrackingID string
+	From, To   Address
+	Parcel     Parcel
+	CreatedAt  time.Time
+}
+
+type Address struct {
+	Name    string
+	Street  string
+	City    string
+	Postal  string
+	Country string
+}
+
+type Parcel struct {
+	WeightKg float64
+	LengthCm float64
+	WidthCm  float64
+	HeightCm float64
+}
+
+// shipInput: SHIP 입력 — {quote, shipment: QuoteInput} 또는 QuoteInput 그대로
+type shipInput struct {
+	Quote    map[string]any `json:"quote"`
+	Shipment *quoteInput    `json:"shipment"`
+	quoteInput
+}
+
+type quoteInput struct {
+	From    map[string]any `json:"from"`
+	To      map[string]any `json:"to"`
+	Parcel  map[string]any `json:"parcel"`
+	Options map[string]any `json:"options"`
+}
+
+// FromShipInput: SHIP 입력에서 보내는/받는 사람, 소포, 서비스 등급을 뽑는다(없는 값은 빈칸)
+func FromShipInput(carrier, trackingID string, input json.RawMessage) (Label, error) {
+	l := Label{Carrier: carrier, TrackingID: trackingID, Service: "STANDARD", CreatedAt: time.Now().UTC()}
+	if len(input) == 0 {
+		return l, nil
+	}
+	var in shipInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return Label{}, fmt.Errorf("label: %w", err)
+	}
+	q := in.quoteInput
+	if in.Shipment != nil {
+		q = *in.Shipment
+	}
+	l.From, l.To = address(q.From), address(q.To)
+	l.Parcel = Parcel{
+		WeightKg: number(q.Parcel["weight_kg"]),
+		LengthCm: number(q.Parcel["l_cm"]),
+		WidthCm:  number(q.Parcel["w_cm"]),
+		HeightCm: number(q.Parcel["h_cm"]),
+	}
+	switch s, _ := in.Quote["service"].(string); {
+	case s != "":
+		l.Service = strings.ToUpper(s)
+	case q.Options["priority"] == true:
+		l.Service = "EXPRESS"
+	}
+	return l, nil
+}
+
+func address(m map[string]any) Address {
+	str := func(keys ...string) string {
+		for _, k := range keys {
+			if s, ok := m[k].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+	return Address{
+		Name:    str("name", "company"),
+		Street:  str("street", "address", "line1"),
+		City:    str("city"),
+		Postal:  str("postal", "postal_code", "zip"),
+		Country: strings.ToUpper(str("country")),
+	}
+}
+
+func number(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+// Lines: 주소 블록(빈 줄 생략, 최소 한 줄)
+func (a Address) Lines() []string {
+	var out []string
+	for _, s := range []string{a.Name, a.Street, strings.TrimSpace(a.City + " " + a.Postal), a.Country} {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		out = []string{"-"}
+	}
+	return out
+}
+
+func (p Parcel) String() string {
+	return fmt.Sprintf("%.2f kg  %gx%gx%g cm", p.WeightKg, p.LengthCm, p.WidthCm, p.HeightCm)
+}
+
+// QRPayload: QR 코드 내용 — 스캐너가 바로 읽을 수 있는 JSON
+func (l Label) QRPayload() string {
+	b, _ := json.Marshal(map[string]string{
+		"carrier":     l.Carrier,
+		"tracking_id": l.TrackingID,
+		"service":     l.Service,
+		"to":          strings.TrimSpace(l.To.Country + " " + l.To.Postal),
+	})
+	return string(b)
+}
+
+// ascii: 내장 폰트(PDF 코어 폰트, basicfont)가 못 그리는 문자는 '?'로
+func ascii(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return '?'
+		}
+		return r
+	}, s)
+}
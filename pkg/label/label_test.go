@@ -0,0 +1,162 @@
+package label
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+func testLabel() Label {
+	return Label{
+		Carrier:    "AgentA",
+		Service:    "EXPRESS",
+		TrackingID: "A-0123456789abcdef",
+		From:       Address{Name: "Kim", Street: "1 Sejong-daero", City: "Seoul", Postal: "04524", Country: "KR"},
+		To:         Address{Name: "Lee", Street: "500 Market St", City: "San Francisco", Postal: "94105", Country: "US"},
+		Parcel:     Parcel{WeightKg: 1.5, LengthCm: 30, WidthCm: 20, HeightCm: 15},
+		CreatedAt:  time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+// 저장소에 넣고 서명 URL로 내려받은 내용
+func download(t *testing.T, name, mimeType string, body []byte) ([]byte, http.Header) {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	store, err := a2a.NewArtifactStore(t.TempDir(), []byte("test-secret"), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.Handle("/artifacts/", store)
+
+	a, err := store.PutBytes(name, mimeType, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Size != int64(len(body)) {
+		t.Fatalf("size = %d, want %d", a.Size, len(body))
+	}
+	url := store.Sign(a).URL
+
+	resp, err := http.Get(strings.Replace(url, "sig=", "sig=0", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("tampered url: status %d, want 403", resp.StatusCode)
+	}
+
+	resp, err = http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("download: status %d", resp.StatusCode)
+	}
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("downloaded %d bytes, differs from stored %d bytes", len(got), len(body))
+	}
+	return got, resp.Header
+}
+
+func decode(t *testing.T, img image.Image, r gozxing.Reader) string {
+	t.Helper()
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := r.Decode(bmp, map[gozxing.DecodeHintType]any{gozxing.DecodeHintType_TRY_HARDER: true})
+	if err != nil {
+		t.Fatalf("%T: %v", r, err)
+	}
+	return res.GetText()
+}
+
+func TestPNGLabel(t *testing.T) {
+	l := testLabel()
+	b, err := l.PNG()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, hdr := download(t, "label.png", "image/png", b)
+	if ct := hdr.Get("Content-Type"); ct != "image/png" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	img, err := png.Decode(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sz := img.Bounds().Size(); sz != image.Pt(pngW, pngH) {
+		t.Errorf("size = %v", sz)
+	}
+	if s := decode(t, img, oned.NewCode128Reader()); s != l.TrackingID {
+		t.Errorf("code128 = %q, want %q", s, l.TrackingID)
+	}
+	if s := decode(t, img, qrcode.NewQRCodeReader()); s != l.QRPayload() {
+		t.Errorf("qr = %q, want %q", s, l.QRPayload())
+	}
+}
+
+func TestPDFLabel(t *testing.T) {
+	l := testLabel()
+	b, err := l.PDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := l.PDF()
+	if !bytes.Equal(b, again) {
+		t.Error("same label rendered to different PDF bytes")
+	}
+	got, hdr := download(t, "label.pdf", "application/pdf", b)
+	if ct := hdr.Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !bytes.HasPrefix(got, []byte("%PDF-")) || !bytes.Contains(got[len(got)-32:], []byte("%%EOF")) {
+		t.Fatal("not a complete PDF")
+	}
+	// PDF에 들어가는 바코드 이미지(placePNG가 쓰는 것과 같은 크기)도 읽히는지
+	bc, err := code128Image(l.TrackingID, 1600, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := decode(t, bc, oned.NewCode128Reader()); s != l.TrackingID {
+		t.Errorf("code128 = %q, want %q", s, l.TrackingID)
+	}
+	qrc, err := qrImage(l.QRPayload(), 400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := decode(t, qrc, qrcode.NewQRCodeReader()); s != l.QRPayload() {
+		t.Errorf("qr = %q, want %q", s, l.QRPayload())
+	}
+}
+
+func TestFromShipInput(t *testing.T) {
+	in := `{"quote":{"service":"ECONOMY"},"shipment":{"from":{"country":"kr","city":"Seoul"},"to":{"country":"US","postal":"94105"},"parcel":{"weight_kg":"2.5","l_cm":40}}}`
+	l, err := FromShipInput("AgentB", "B-1", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Service != "ECONOMY" || l.From.Country != "KR" || l.To.Postal != "94105" || l.Parcel.WeightKg != 2.5 || l.Parcel.LengthCm != 40 {
+		t.Errorf("label = %+v", l)
+	}
+}
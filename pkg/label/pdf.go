@@ -0,0 +1,108 @@
+package label
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"codeberg.org/go-pdf/fpdf"
+)
+
+// 4x6 inch 감열 라벨(mm)
+const (
+	pageW, pageH = 101.6, 152.4
+	margin       = 5.0
+)
+
+// PDF: 4x6 inch 라벨 한 페이지. 같은 Label이면 같은 바이트(생성 시각 고정).
+func (l Label) PDF() ([]byte, error) {
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "mm", Size: fpdf.SizeType{Wd: pageW, Ht: pageH}})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetCatalogSort(true) // 이미지/폰트 객체 순서 고정(같은 Label → 같은 바이트)
+	pdf.SetCreationDate(l.CreatedAt)
+	pdf.SetModificationDate(l.CreatedAt)
+	pdf.SetTitle("Shipping label "+l.TrackingID, false)
+	pdf.SetCreator(l.Carrier, false)
+	pdf.AddPage()
+	w := pageW - 2*margin
+
+	// 헤더: 캐리어 / 서비스 등급(반전)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(w*0.55, 10, ascii(l.Carrier), "", 0, "L", false, 0, "")
+	pdf.SetFillColor(0, 0, 0)
+	pdf.SetTextColor(255, 255, 255)
+	pdf.CellFormat(w*0.45, 10, ascii(l.Service), "", 1, "C", true, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	rule(pdf)
+
+	block := func(title string, a Address, size float64) {
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(w, 4, title, "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "B", size)
+		pdf.MultiCell(w, size*0.45, ascii(strings.Join(a.Lines(), "\n")), "", "L", false)
+		rule(pdf)
+	}
+	block("FROM", l.From, 9)
+	block("SHIP TO", l.To, 14)
+
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(w, 5, "PARCEL  "+l.Parcel.String(), "", 1, "L", false, 0, "")
+	pdf.CellFormat(w, 5, "DATE    "+l.CreatedAt.Format("2006-01-02"), "", 1, "L", false, 0, "")
+	rule(pdf)
+
+	// 트래킹 번호: Code128 + 사람이 읽는 텍스트
+	bc, err := code128Image(l.TrackingID, 1600, 200)
+	if err != nil {
+		return nil, err
+	}
+	y := pdf.GetY() + 2
+	if err := placePNG(pdf, "code128", bc, margin, y, w, 22); err != nil {
+		return nil, err
+	}
+	pdf.SetXY(margin, y+23)
+	pdf.SetFont("Courier", "B", 9)
+	pdf.CellFormat(w, 5, ascii(l.TrackingID), "", 1, "C", false, 0, "")
+
+	// QR: 하단 좌측
+	qrc, err := qrImage(l.QRPayload(), 400)
+	if err != nil {
+		return nil, err
+	}
+	const qrSize = 32.0
+	if err := placePNG(pdf, "qr", qrc, margin, pageH-margin-qrSize, qrSize, qrSize); err != nil {
+		return nil, err
+	}
+	pdf.SetXY(margin+qrSize+3, pageH-margin-qrSize/2-4)
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.MultiCell(w-qrSize-3, 3.5, "Scan for tracking\n"+ascii(l.Carrier), "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func rule(pdf *fpdf.Fpdf) {
+	y := pdf.GetY() + 1
+	pdf.SetLineWidth(0.6)
+	pdf.Line(margin, y, pageW-margin, y)
+	pdf.SetY(y + 2)
+}
+
+// placePNG: 바코드 이미지를 PNG로 등록해 (x,y,w,h)mm에 그린다
+func placePNG(pdf *fpdf.Fpdf, name string, img image.Image, x, y, w, h float64) error {
+	gray := image.NewGray(img.Bounds()) // fpdf는 16bit PNG를 못 읽음(바코드는 Gray16)
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, gray); err != nil {
+		return err
+	}
+	opt := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(name, opt, &buf)
+	pdf.ImageOptions(name, x, y, w, h, false, opt, 0, "")
+	return pdf.Error()
+}
@@ -0,0 +1,109 @@
+package label
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// 203dpi 4x6 inch 감열 프린터 해상도(px)
+const (
+	pngW, pngH = 812, 1218
+	pngMargin  = 40
+)
+
+// PNG: PDF와 같은 배치의 라벨 이미지(흑백)
+func (l Label) PNG() ([]byte, error) {
+	img := image.NewGray(image.Rect(0, 0, pngW, pngH))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	w := pngW - 2*pngMargin
+	y := pngMargin
+
+	// 헤더: 캐리어 / 서비스 등급(반전)
+	text(img, pngMargin, y+6, l.Carrier, 3, color.Black)
+	svc := image.Rect(pngMargin+w*55/100, y-8, pngMargin+w, y+60)
+	draw.Draw(img, svc, image.Black, image.Point{}, draw.Src)
+	text(img, svc.Min.X+(svc.Dx()-textWidth(l.Service, 4))/2, y, l.Service, 4, color.White)
+	y = hrule(img, y+70)
+
+	block := func(title string, a Address, scale int) {
+		text(img, pngMargin, y, title, 2, color.Black)
+		y += 30
+		for _, line := range a.Lines() {
+			text(img, pngMargin, y, line, scale, color.Black)
+			y += 13*scale + 6
+		}
+		y = hrule(img, y)
+	}
+	block("FROM", l.From, 2)
+	block("SHIP TO", l.To, 3)
+
+	text(img, pngMargin, y, "PARCEL  "+l.Parcel.String(), 2, color.Black)
+	text(img, pngMargin, y+32, "DATE    "+l.CreatedAt.Format("2006-01-02"), 2, color.Black)
+	y = hrule(img, y+64)
+
+	// 트래킹 번호: Code128 + 텍스트
+	bc, err := code128Image(l.TrackingID, w, 170)
+	if err != nil {
+		return nil, err
+	}
+	bx := pngMargin + (w-bc.Bounds().Dx())/2
+	draw.Draw(img, bc.Bounds().Add(image.Pt(bx, y+10)), bc, image.Point{}, draw.Src)
+	y += 10 + bc.Bounds().Dy() + 8
+	text(img, pngMargin+(w-textWidth(l.TrackingID, 2))/2, y, l.TrackingID, 2, color.Black)
+
+	// QR: 하단 좌측
+	qrc, err := qrImage(l.QRPayload(), 260)
+	if err != nil {
+		return nil, err
+	}
+	qy := pngH - pngMargin - qrc.Bounds().Dy()
+	draw.Draw(img, qrc.Bounds().Add(image.Pt(pngMargin, qy)), qrc, image.Point{}, draw.Src)
+	text(img, pngMargin+qrc.Bounds().Dx()+24, qy+qrc.Bounds().Dy()/2-26, "Scan for tracking", 2, color.Black)
+	text(img, pngMargin+qrc.Bounds().Dx()+24, qy+qrc.Bounds().Dy()/2+4, l.Carrier, 2, color.Black)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func hrule(img *image.Gray, y int) int {
+	draw.Draw(img, image.Rect(pngMargin, y+4, pngW-pngMargin, y+8), image.Black, image.Point{}, draw.Src)
+	return y + 20
+}
+
+func textWidth(s string, scale int) int {
+	return len(ascii(s)) * basicfont.Face7x13.Advance * scale
+}
+
+// text: basicfont(7x13)로 그린 뒤 정수 배 확대 — 외부 폰트 파일 없이 큰 글씨. (x,y)는 글자 상단.
+func text(dst *image.Gray, x, y int, s string, scale int, c color.Color) {
+	s = ascii(strings.TrimSpace(s))
+	if s == "" {
+		return
+	}
+	face := basicfont.Face7x13
+	small := image.NewAlpha(image.Rect(0, 0, len(s)*face.Advance, face.Height))
+	d := font.Drawer{Dst: small, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Ascent)}
+	d.DrawString(s)
+	src := image.NewUniform(c)
+	b := small.Bounds()
+	for sy := b.Min.Y; sy < b.Max.Y; sy++ {
+		for sx := b.Min.X; sx < b.Max.X; sx++ {
+			if small.AlphaAt(sx, sy).A < 0x80 {
+				continue
+			}
+			r := image.Rect(x+sx*scale, y+sy*scale, x+(sx+1)*scale, y+(sy+1)*scale)
+			draw.Draw(dst, r.Intersect(dst.Bounds()), src, image.Point{}, draw.Src)
+		}
+	}
+}
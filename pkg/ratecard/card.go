@@ -0,0 +1,244 @@
+// Package ratecard: 캐리어 요율표(존/무게 구간/부피 무게/할증/서비스별 ETA)와 견적 계산
+package ratecard
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Card: 캐리어 하나의 요율표. YAML 파일 하나, 또는 YAML + rates_csv, 또는 CSV 파일 하나로 정의.
+type Card struct {
+	Carrier    string    `yaml:"carrier"`
+	Currency   string    `yaml:"currency"`
+	Precision  int       `yaml:"precision"`   // 가격 소수 자릿수(KRW 0, USD 2)
+	DimDivisor float64   `yaml:"dim_divisor"` // 부피 무게(kg) = l*w*h(cm) / divisor, 기본 5000
+	WeightStep float64   `yaml:"weight_step"` // 청구 무게 올림 단위, 기본 0.5kg
+	FuelPct    float64   `yaml:"fuel_pct"`    // 유류 할증: (기본료+우선 할증)의 %
+	Priority   Surcharge `yaml:"priority"`    // options.priority 할증
+	Zones      []Zone    `yaml:"zones"`       // 위에서부터 처음 맞는 존
+	Services   []Service `yaml:"services"`
+	Rates      []Rate    `yaml:"rates"`
+	RatesCSV   string    `yaml:"rates_csv"` // 카드 파일 기준 상대 경로
+}
+
+// Surcharge: 고정 금액 + 기본료 대비 %. 우선 처리면 ETA도 줄어든다.
+type Surcharge struct {
+	Flat        float64 `yaml:"flat"`
+	Pct         float64 `yaml:"pct"`
+	EtaDaysLess int     `yaml:"eta_days_less"`
+}
+
+// Zone: 출발/도착 국가(ISO2) 쌍. "*"는 모든 국가.
+type Zone struct {
+	Name string   `yaml:"name"`
+	From []string `yaml:"from"`
+	To   []string `yaml:"to"`
+}
+
+// Service: 서비스 등급과 존별 ETA(일). "*" 키는 기본값.
+type Service struct {
+	Name    string         `yaml:"name"`
+	EtaDays map[string]int `yaml:"eta_days"`
+}
+
+// Rate: 무게 구간 — 청구 무게가 MaxKg 이하이면 Price. 마지막 구간을 넘으면 kg당 ExtraPerKg(0이면 취급 안 함).
+type Rate struct {
+	Service    string  `yaml:"service"`
+	Zone       string  `yaml:"zone"`
+	MaxKg      float64 `yaml:"max_kg"`
+	Price      float64 `yaml:"price"`
+	ExtraPerKg float64 `yaml:"extra_per_kg"`
+}
+
+// Load: 확장자로 형식 결정(.yaml/.yml, .csv)
+func Load(path string) (*Card, error) {
+	c := &Card{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(b, c); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if c.RatesCSV != "" {
+			p := c.RatesCSV
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(filepath.Dir(path), p)
+			}
+			if err := c.loadCSV(p); err != nil {
+				return nil, err
+			}
+		}
+	case ".csv":
+		if err := c.loadCSV(path); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported rate card format", path)
+	}
+	if err := c.normalize(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// loadCSV: 헤더 필수. service,zone,max_kg,price[,extra_per_kg] 에
+// from,to(‘|’ 구분)와 eta_days 열을 더하면 존/ETA까지 CSV 하나로 정의할 수 있다.
+func (c *Card) loadCSV(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comment = '#'
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%s: header: %w", path, err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, need := range []string{"service", "zone", "max_kg", "price"} {
+		if _, ok := col[need]; !ok {
+			return fmt.Errorf("%s: missing column %q", path, need)
+		}
+	}
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		line, _ := r.FieldPos(0)
+		get := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		num := func(name string) (float64, error) {
+			s := get(name)
+			if s == "" {
+				return 0, nil
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return 0, fmt.Errorf("%s:%d: %s: %w", path, line, name, err)
+			}
+			return v, nil
+		}
+		rt := Rate{Service: get("service"), Zone: get("zone")}
+		if rt.MaxKg, err = num("max_kg"); err != nil {
+			return err
+		}
+		if rt.Price, err = num("price"); err != nil {
+			return err
+		}
+		if rt.ExtraPerKg, err = num("extra_per_kg"); err != nil {
+			return err
+		}
+		c.Rates = append(c.Rates, rt)
+
+		if from, to := get("from"), get("to"); from != "" || to != "" {
+			c.addZone(Zone{Name: rt.Zone, From: splitCodes(from), To: splitCodes(to)})
+		}
+		if eta := get("eta_days"); eta != "" {
+			d, err := strconv.Atoi(eta)
+			if err != nil {
+				return fmt.Errorf("%s:%d: eta_days: %w", path, line, err)
+			}
+			c.service(rt.Service).EtaDays[rt.Zone] = d
+		}
+	}
+}
+
+func (c *Card) addZone(z Zone) {
+	if !slices.ContainsFunc(c.Zones, func(x Zone) bool { return x.Name == z.Name }) {
+		c.Zones = append(c.Zones, z)
+	}
+}
+
+func (c *Card) service(name string) *Service {
+	for i := range c.Services {
+		if c.Services[i].Name == name {
+			if c.Services[i].EtaDays == nil {
+				c.Services[i].EtaDays = map[string]int{}
+			}
+			return &c.Services[i]
+		}
+	}
+	c.Services = append(c.Services, Service{Name: name, EtaDays: map[string]int{}})
+	return &c.Services[len(c.Services)-1]
+}
+
+func splitCodes(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, "|") {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// normalize: 기본값 채우고 참조 검사, 요율은 (서비스, 존, 무게) 순 정렬
+func (c *Card) normalize() error {
+	if c.Currency == "" {
+		c.Currency = "KRW"
+	}
+	c.Currency = strings.ToUpper(c.Currency)
+	if c.DimDivisor <= 0 {
+		c.DimDivisor = 5000
+	}
+	if c.WeightStep <= 0 {
+		c.WeightStep = 0.5
+	}
+	if len(c.Rates) == 0 {
+		return errors.New("no rates")
+	}
+	for i := range c.Zones {
+		c.Zones[i].From = splitCodes(strings.Join(c.Zones[i].From, "|"))
+		c.Zones[i].To = splitCodes(strings.Join(c.Zones[i].To, "|"))
+	}
+	for _, r := range c.Rates {
+		if !slices.ContainsFunc(c.Zones, func(z Zone) bool { return z.Name == r.Zone }) {
+			return fmt.Errorf("rate %s/%s: unknown zone", r.Service, r.Zone)
+		}
+		s := c.service(r.Service)
+		if _, ok := s.EtaDays[r.Zone]; !ok {
+			if _, ok := s.EtaDays["*"]; !ok {
+				return fmt.Errorf("service %s: no eta_days for zone %s", r.Service, r.Zone)
+			}
+		}
+		if r.MaxKg <= 0 || r.Price < 0 {
+			return fmt.Errorf("rate %s/%s: max_kg must be > 0 and price >= 0", r.Service, r.Zone)
+		}
+	}
+	slices.SortStableFunc(c.Rates, func(a, b Rate) int {
+		switch {
+		case a.Service != b.Service:
+			return strings.Compare(a.Service, b.Service)
+		case a.Zone != b.Zone:
+			return strings.Compare(a.Zone, b.Zone)
+		}
+		return cmp.Compare(a.MaxKg, b.MaxKg)
+	})
+	return nil
+}
@@ -0,0 +1,84 @@
+package ratecard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCSV(t *testing.T) {
+	// CSV 하나로 존/ETA까지 정의
+	p := writeCard(t, "card.csv", `# comment
+service, zone, max_kg, price, extra_per_kg, from, to, eta_days
+STANDARD, dom, 1, 5000, , KR, KR, 2
+STANDARD, dom, 5, 9000, 1000, KR, KR, 2
+EXPRESS, intl, 1, 30000, , KR, US|jp, 3
+`)
+	c, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Currency != "KRW" || c.DimDivisor != 5000 || c.WeightStep != 0.5 {
+		t.Errorf("defaults = %s %v %v", c.Currency, c.DimDivisor, c.WeightStep)
+	}
+	if z, ok := c.Zone("KR", "JP"); !ok || z != "intl" {
+		t.Errorf("Zone(KR, JP) = %q, %v", z, ok)
+	}
+	qs, err := c.Quote(Request{From: "KR", To: "KR", WeightKg: 6})
+	if err != nil || qs[0].Price != 10000 || qs[0].EtaDays != 2 {
+		t.Errorf("quote = %+v, %v", qs, err)
+	}
+}
+
+func TestLoadYAMLWithRatesCSV(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "rates.csv"), []byte("service,zone,max_kg,price\nSTANDARD,all,10,100\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "card.yml"), []byte(`
+carrier: T
+currency: usd
+precision: 2
+zones: [{name: all, from: ["*"], to: ["*"]}]
+services: [{name: STANDARD, eta_days: {"*": 4}}]
+rates_csv: rates.csv
+`), 0o644)
+	c, err := Load(filepath.Join(dir, "card.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Currency != "USD" || len(c.Rates) != 1 || c.Rates[0].Price != 100 {
+		t.Errorf("card = %+v", c)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	const zones = `zones: [{name: all, from: ["*"], to: ["*"]}]
+services: [{name: S, eta_days: {all: 1}}]
+`
+	tests := []struct {
+		name, file, body, want string
+	}{
+		{"format", "card.json", `{}`, "unsupported rate card format"},
+		{"yaml syntax", "card.yaml", "rates: [", "card.yaml"},
+		{"no rates", "card.yaml", zones, "no rates"},
+		{"unknown zone", "card.yaml", zones + "rates: [{service: S, zone: nope, max_kg: 1, price: 1}]", "unknown zone"},
+		{"missing eta", "card.yaml", zones + "rates: [{service: T, zone: all, max_kg: 1, price: 1}]", "no eta_days"},
+		{"bad band", "card.yaml", zones + "rates: [{service: S, zone: all, max_kg: 0, price: 1}]", "max_kg must be > 0"},
+		{"negative price", "card.yaml", zones + "rates: [{service: S, zone: all, max_kg: 1, price: -1}]", "price >= 0"},
+		{"missing rates_csv", "card.yaml", zones + "rates_csv: nope.csv", "nope.csv"},
+		{"csv empty", "card.csv", "", "header"},
+		{"csv missing column", "card.csv", "service,zone,max_kg\nS,all,1\n", `missing column "price"`},
+		{"csv bad number", "card.csv", "service,zone,max_kg,price,from,to,eta_days\nS,all,1,x,*,*,1\n", "card.csv:2: price"},
+		{"csv bad eta", "card.csv", "service,zone,max_kg,price,from,to,eta_days\nS,all,1,1,*,*,soon\n", "card.csv:2: eta_days"},
+		{"csv ragged", "card.csv", "service,zone,max_kg,price\nS,all,1\n", "wrong number of fields"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeCard(t, tt.file, tt.body))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("missing file: want error")
+	}
+}
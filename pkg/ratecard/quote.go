@@ -0,0 +1,164 @@
+package ratecard
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ErrNoRate: 이 카드로 견적을 낼 수 없는 구간(존 없음, 무게 초과)
+var ErrNoRate = errors.New("no rate")
+
+// Request: QUOTE.input에서 요율 계산에 필요한 값
+type Request struct {
+	From, To string // ISO2
+	WeightKg float64
+	L, W, H  float64 // cm
+	Priority bool
+}
+
+// Quote: 서비스 하나의 견적
+type Quote struct {
+	Carrier    string    `json:"carrier"`
+	Service    string    `json:"service"`
+	Zone       string    `json:"zone"`
+	Price      float64   `json:"price"`
+	Currency   string    `json:"currency"`
+	EtaDays    int       `json:"eta_days"`
+	BillableKg float64   `json:"billable_weight_kg"`
+	Breakdown  Breakdown `json:"breakdown"`
+}
+
+type Breakdown struct {
+	Base     float64 `json:"base"`
+	Priority float64 `json:"priority,omitempty"`
+	Fuel     float64 `json:"fuel,omitempty"`
+}
+
+// ParseRequest: QuoteInput JSON({from,to,parcel,options}) → Request
+func ParseRequest(input json.RawMessage) (Request, error) {
+	var in struct {
+		From   struct{ Country string } `json:"from"`
+		To     struct{ Country string } `json:"to"`
+		Parcel struct {
+			WeightKg float64 `json:"weight_kg"`
+			L        float64 `json:"l_cm"`
+			W        float64 `json:"w_cm"`
+			H        float64 `json:"h_cm"`
+		} `json:"parcel"`
+		Options struct {
+			Priority bool `json:"priority"`
+		} `json:"options"`
+	}
+	if err := json.Unmarshal(input, &in); err != nil {
+		return Request{}, err
+	}
+	r := Request{
+		From: strings.ToUpper(in.From.Country), To: strings.ToUpper(in.To.Country),
+		WeightKg: in.Parcel.WeightKg, L: in.Parcel.L, W: in.Parcel.W, H: in.Parcel.H,
+		Priority: in.Options.Priority,
+	}
+	switch {
+	case r.From == "" || r.To == "":
+		return Request{}, errors.New("from.country and to.country are required")
+	case r.WeightKg <= 0:
+		return Request{}, errors.New("parcel.weight_kg must be > 0")
+	case r.L < 0 || r.W < 0 || r.H < 0:
+		return Request{}, errors.New("parcel dimensions must be >= 0")
+	}
+	return r, nil
+}
+
+// Zone: 출발/도착 국가에 처음 맞는 존
+func (c *Card) Zone(from, to string) (string, bool) {
+	in := func(list []string, code string) bool {
+		return slices.Contains(list, "*") || slices.Contains(list, code)
+	}
+	for _, z := range c.Zones {
+		if in(z.From, from) && in(z.To, to) {
+			return z.Name, true
+		}
+	}
+	return "", false
+}
+
+// BillableKg: max(실무게, 부피 무게)를 WeightStep 단위로 올림
+func (c *Card) BillableKg(r Request) float64 {
+	w := max(r.WeightKg, r.L*r.W*r.H/c.DimDivisor)
+	return math.Ceil(w/c.WeightStep-1e-9) * c.WeightStep
+}
+
+// Quote: 서비스별 견적(가격 오름차순). 취급하는 서비스가 없으면 ErrNoRate.
+func (c *Card) Quote(r Request) ([]Quote, error) {
+	zone, ok := c.Zone(r.From, r.To)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s→%s is not served", ErrNoRate, r.From, r.To)
+	}
+	kg := c.BillableKg(r)
+	var out []Quote
+	for _, s := range c.Services {
+		base, ok := c.base(s.Name, zone, kg)
+		if !ok {
+			continue
+		}
+		q := Quote{Carrier: c.Carrier, Service: s.Name, Zone: zone, Currency: c.Currency, BillableKg: kg}
+		q.Breakdown.Base = c.round(base)
+		eta, ok := s.EtaDays[zone]
+		if !ok {
+			eta = s.EtaDays["*"]
+		}
+		if r.Priority {
+			q.Breakdown.Priority = c.round(c.Priority.Flat + base*c.Priority.Pct/100)
+			eta -= c.Priority.EtaDaysLess
+		}
+		q.Breakdown.Fuel = c.round((q.Breakdown.Base + q.Breakdown.Priority) * c.FuelPct / 100)
+		q.Price = c.round(q.Breakdown.Base + q.Breakdown.Priority + q.Breakdown.Fuel)
+		q.EtaDays = max(eta, 1)
+		out = append(out, q)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: %.1fkg exceeds limits for zone %s", ErrNoRate, kg, zone)
+	}
+	slices.SortStableFunc(out, func(a, b Quote) int {
+		return cmp.Or(cmp.Compare(a.Price, b.Price), cmp.Compare(a.EtaDays, b.EtaDays))
+	})
+	return out, nil
+}
+
+// base: 무게 구간 요금. 마지막 구간 초과분은 kg당 ExtraPerKg(1kg 단위 올림).
+func (c *Card) base(service, zone string, kg float64) (float64, bool) {
+	var last *Rate
+	for i := range c.Rates {
+		r := &c.Rates[i]
+		if r.Service != service || r.Zone != zone {
+			continue
+		}
+		if kg <= r.MaxKg {
+			return r.Price, true
+		}
+		last = r
+	}
+	if last == nil || last.ExtraPerKg <= 0 {
+		return 0, false
+	}
+	return last.Price + math.Ceil(kg-last.MaxKg)*last.ExtraPerKg, true
+}
+
+func (c *Card) round(v float64) float64 {
+	p := math.Pow10(c.Precision)
+	return math.Round(v*p) / p
+}
+
+// Best: 우선 처리면 가장 빠른 것(같으면 싼 것), 아니면 가장 싼 것
+func Best(qs []Quote, fastest bool) Quote {
+	if !fastest {
+		return qs[0]
+	}
+	return slices.MinFunc(qs, func(a, b Quote) int {
+		return cmp.Or(cmp.Compare(a.EtaDays, b.EtaDays), cmp.Compare(a.Price, b.Price))
+	})
+}
@@ -0,0 +1,171 @@
+package ratecard
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testCard = `
+carrier: Test
+currency: KRW
+fuel_pct: 10
+priority: {flat: 1000, pct: 10, eta_days_less: 1}
+zones:
+  - {name: dom, from: [kr], to: [KR]}
+  - {name: asia, from: [KR], to: [JP, CN]}
+  - {name: world, from: ["*"], to: ["*"]}
+  - {name: never, from: [KR], to: [US]}
+services:
+  - {name: STANDARD, eta_days: {dom: 2, "*": 5}}
+  - {name: EXPRESS, eta_days: {"*": 1}}
+rates:
+  - {service: STANDARD, zone: dom, max_kg: 5, price: 9000, extra_per_kg: 1000}
+  - {service: STANDARD, zone: dom, max_kg: 1, price: 5000}
+  - {service: STANDARD, zone: asia, max_kg: 2, price: 15000}
+  - {service: STANDARD, zone: world, max_kg: 2, price: 20000, extra_per_kg: 5000}
+  - {service: EXPRESS, zone: world, max_kg: 1, price: 30000}
+`
+
+func writeCard(t *testing.T, name, body string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func loadTestCard(t *testing.T) *Card {
+	t.Helper()
+	c, err := Load(writeCard(t, "card.yaml", testCard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestZone(t *testing.T) {
+	c := loadTestCard(t)
+	tests := []struct {
+		from, to, want string
+	}{
+		{"KR", "KR", "dom"},
+		{"KR", "JP", "asia"},
+		{"KR", "CN", "asia"},
+		{"JP", "KR", "world"},
+		{"KR", "US", "world"}, // 위에서부터 처음 맞는 존
+	}
+	for _, tt := range tests {
+		if got, ok := c.Zone(tt.from, tt.to); !ok || got != tt.want {
+			t.Errorf("Zone(%s, %s) = %q, %v; want %q", tt.from, tt.to, got, ok, tt.want)
+		}
+	}
+}
+
+func TestBillableKg(t *testing.T) {
+	c := loadTestCard(t)
+	tests := []struct {
+		name string
+		r    Request
+		want float64
+	}{
+		{"exact step", Request{WeightKg: 1}, 1},
+		{"rounds up to step", Request{WeightKg: 1.01}, 1.5},
+		{"float noise", Request{WeightKg: 0.1 + 0.2 + 0.2}, 0.5},
+		{"dimensional wins", Request{WeightKg: 1, L: 50, W: 40, H: 30}, 12},
+		{"actual wins", Request{WeightKg: 3, L: 10, W: 10, H: 10}, 3},
+		{"dimensional rounded", Request{WeightKg: 0.1, L: 20, W: 20, H: 13}, 1.5},
+	}
+	for _, tt := range tests {
+		if got := c.BillableKg(tt.r); got != tt.want {
+			t.Errorf("%s: BillableKg = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	c := loadTestCard(t)
+	tests := []struct {
+		name      string
+		r         Request
+		service   string // 가장 싼 견적
+		price     float64
+		breakdown Breakdown
+		eta       int
+		count     int
+	}{
+		{"lowest band", Request{From: "KR", To: "KR", WeightKg: 0.8}, "STANDARD", 5500, Breakdown{Base: 5000, Fuel: 500}, 2, 1},
+		{"upper band", Request{From: "KR", To: "KR", WeightKg: 1.2}, "STANDARD", 9900, Breakdown{Base: 9000, Fuel: 900}, 2, 1},
+		{"extra_per_kg above last band", Request{From: "KR", To: "KR", WeightKg: 6.5}, "STANDARD", 12100, Breakdown{Base: 11000, Fuel: 1100}, 2, 1},
+		{"priority and fuel", Request{From: "KR", To: "KR", WeightKg: 1, Priority: true}, "STANDARD", 7150, Breakdown{Base: 5000, Priority: 1500, Fuel: 650}, 1, 1},
+		{"services sorted by price", Request{From: "US", To: "DE", WeightKg: 1}, "STANDARD", 22000, Breakdown{Base: 20000, Fuel: 2000}, 5, 2},
+		{"service without extra drops out", Request{From: "US", To: "DE", WeightKg: 3}, "STANDARD", 27500, Breakdown{Base: 25000, Fuel: 2500}, 5, 1},
+	}
+	for _, tt := range tests {
+		qs, err := c.Quote(tt.r)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		q := qs[0]
+		if len(qs) != tt.count || q.Service != tt.service || q.Price != tt.price || q.Breakdown != tt.breakdown || q.EtaDays != tt.eta {
+			t.Errorf("%s: got %d quotes, first %+v", tt.name, len(qs), q)
+		}
+	}
+
+	// 우선 처리로 ETA가 줄어도 1일 미만은 없다
+	qs, err := c.Quote(Request{From: "US", To: "DE", WeightKg: 1, Priority: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fast := Best(qs, true); fast.Service != "EXPRESS" || fast.EtaDays != 1 {
+		t.Errorf("fastest = %+v", fast)
+	}
+}
+
+func TestQuoteNoRate(t *testing.T) {
+	c := loadTestCard(t)
+	// 마지막 구간 초과, extra_per_kg 없음
+	if _, err := c.Quote(Request{From: "KR", To: "JP", WeightKg: 3}); !errors.Is(err, ErrNoRate) {
+		t.Errorf("over last band: err = %v, want ErrNoRate", err)
+	}
+	c.Zones = c.Zones[:1]
+	if _, err := c.Quote(Request{From: "US", To: "DE", WeightKg: 1}); !errors.Is(err, ErrNoRate) {
+		t.Errorf("unserved zone: err = %v, want ErrNoRate", err)
+	}
+}
+
+func TestRoundPrecision(t *testing.T) {
+	c := &Card{Precision: 2}
+	if got := c.round(10.005001); got != 10.01 {
+		t.Errorf("round = %v", got)
+	}
+	c.Precision = 0
+	if got := c.round(1234.5); got != 1235 {
+		t.Errorf("round = %v", got)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Request
+		wantErr bool
+	}{
+		{`{"from":{"country":"kr"},"to":{"country":"us"},"parcel":{"weight_kg":1,"l_cm":10,"w_cm":20,"h_cm":30},"options":{"priority":true}}`,
+			Request{From: "KR", To: "US", WeightKg: 1, L: 10, W: 20, H: 30, Priority: true}, false},
+		{`{"from":{"country":"KR"},"parcel":{"weight_kg":1}}`, Request{}, true},
+		{`{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":0}}`, Request{}, true},
+		{`{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":1,"l_cm":-1}}`, Request{}, true},
+		{`{"from":`, Request{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRequest(json.RawMessage(tt.in))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRequest(%s) = %+v, %v", tt.in, got, err)
+		}
+	}
+}
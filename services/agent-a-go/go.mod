@@ -1,5 +0,0 @@
-module a2a/agent-a
-
-go 1.24.2
-
-require github.com/go-chi/chi/v5 v5.2.3 // indirect
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+
+	a2a "a2a/contract"
+	"a2a/label"
+	"a2a/ratecard"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// carrier: 설정 하나로 띄운 캐리어 에이전트(상태는 인스턴스별 — compose로 여러 개 띄울 수 있음)
+type carrier struct {
+	cfg       *Config
+	card      *ratecard.Card
+	artifacts *a2a.ArtifactStore
+	log       *log.Logger
+	rng       *rng          // 지연/실패 난수(시드 고정)
+	events    *a2a.EventLog // 비동기 완료 콜백 seq
+	signer    *a2a.Signer   // 콜백 서명 — A2A_SECRET이 없으면 서명하지 않음
+
+	mu        sync.Mutex
+	m         map[string]*a2a.Task
+	keys      map[string]*idemCall // idempotency_key → 처리 중이거나 끝난 task
+	shipments map[string]*shipment // tracking_id → 추적 계획
+}
+
+// task_type별 스키마 이름(discovery용)
+var schemas = map[string][2]string{
+	"QUOTE":         {"QuoteRequest", "QuoteResult"},
+	"SHIP":          {"ShipRequest", "ShipResult"},
+	"VOID_SHIPMENT": {"VoidRequest", "VoidResult"},
+	"TRACK":         {"TrackRequest", "TrackResult"},
+}
+
+func newCarrier(cfg *Config) (*carrier, error) {
+	for _, tt := range cfg.TaskTypes {
+		if _, ok := schemas[tt]; !ok {
+			return nil, fmt.Errorf("%s: unknown task_type %s", cfg.AgentID, tt)
+		}
+	}
+	card, err := ratecard.Load(cfg.RateCard)
+	if err != nil {
+		return nil, fmt.Errorf("%s: rate card: %w", cfg.AgentID, err)
+	}
+	if cfg.Carrier != "" {
+		card.Carrier = cfg.Carrier
+	}
+	if card.Carrier == "" {
+		card.Carrier = cfg.AgentID
+	}
+	if len(cfg.Services) > 0 {
+		card.Services = slices.DeleteFunc(card.Services, func(s ratecard.Service) bool { return !slices.Contains(cfg.Services, s.Name) })
+		if len(card.Services) == 0 {
+			return nil, fmt.Errorf("%s: none of services %v in rate card", cfg.AgentID, cfg.Services)
+		}
+	}
+	// 라벨 등 결과 파일 저장소 — 다운로드 URL은 ARTIFACT_SECRET(없으면 저장소에 보관한 키)으로 서명
+	artifacts, err := a2a.NewArtifactStore(cfg.ArtifactDir, []byte(os.Getenv("ARTIFACT_SECRET")), cfg.PublicURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.AgentID, err)
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Uint64()
+	}
+	return &carrier{
+		cfg: cfg, card: card, artifacts: artifacts,
+		log:    log.New(os.Stderr, "["+cfg.AgentID+"] ", log.LstdFlags),
+		rng:    newRNG(cfg.Seed),
+		events: a2a.NewEventLog(),
+		signer: &a2a.Signer{AgentID: cfg.AgentID, Secret: []byte(os.Getenv("A2A_SECRET"))},
+		m:      map[string]*a2a.Task{}, keys: map[string]*idemCall{}, shipments: map[string]*shipment{},
+	}, nil
+}
+
+func (c *carrier) routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(a2a.DeadlineMiddleware)
+
+	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ok")) })
+	r.Get("/.well-known/agent.json", c.meta)
+	r.Get("/artifacts/{sha}", c.artifacts.ServeHTTP)
+
+	r.Group(func(r chi.Router) {
+		r.Use(c.simulate) // 지연/실패 주입은 task API에만
+		r.Post("/tasks", c.createTask)
+		r.Get("/tasks/{id}", c.getTask)
+	})
+	// 비동기 이벤트 수신 엔드포인트(에이전트 입장에선 보통 사용 X — 형태만 제공)
+	r.Post("/tasks/{id}/events", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(204) })
+	return r
+}
+
+func (c *carrier) meta(w http.ResponseWriter, _ *http.Request) {
+	meta := a2a.AgentMeta{
+		AgentID: c.cfg.AgentID, Name: c.cfg.Name, Version: "0.2.0",
+		ContractVer: a2a.ContractVersion,
+		Auth:        &a2a.AuthSpec{Required: false, Scheme: "HMAC"},
+	}
+	for _, tt := range c.cfg.TaskTypes {
+		meta.Capabilities = append(meta.Capabilities, a2a.AgentCapability{TaskType: tt, InputSchema: schemas[tt][0], OutputSchema: schemas[tt][1]})
+	}
+	json.NewEncoder(w).Encode(meta)
+}
+
+func (c *carrier) createTask(w http.ResponseWriter, r *http.Request) {
+	var ct a2a.CreateTask
+	if err := json.NewDecoder(r.Body).Decode(&ct); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.Task{Error: a2a.NewError(a2a.ErrValidationFailed, err.Error())})
+		return
+	}
+	if err := a2a.ValidateCreateTask(&ct); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.Task{Error: a2a.NewError(a2a.ErrValidationFailed, err.Error())})
+		return
+	}
+	// 같은 idempotency_key 재요청이면 기존 task를 그대로 반환 — 키는 처리 전에 잠금 안에서 예약하고,
+	// 처리 중에 온 재요청은 끝날 때까지 기다린다(동시 재시도로 라벨이 두 번 만들어지지 않게).
+	// 같은 키로 다른 요청(task_type/input)이 오면 CONFLICT.
+	taskID := a2a.NewID("t_")
+	if ct.IdempotencyKey != "" {
+		req := fingerprint(ct)
+		c.mu.Lock()
+		call, ok := c.keys[ct.IdempotencyKey]
+		if !ok {
+			call = &idemCall{taskID: taskID, req: req, done: make(chan struct{})}
+			c.keys[ct.IdempotencyKey] = call
+		}
+		c.mu.Unlock()
+		if ok && call.req != req {
+			w.WriteHeader(409)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrConflict, "idempotency_key was used with a different request"))
+			return
+		}
+		if ok {
+			select {
+			case <-call.done:
+			case <-r.Context().Done():
+				w.WriteHeader(409)
+				json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrConflict, "request with the same idempotency_key is still in progress"))
+				return
+			}
+			c.mu.Lock()
+			t := c.m[call.taskID]
+			c.mu.Unlock()
+			json.NewEncoder(w).Encode(map[string]any{"task_id": call.taskID, "status": t.Status})
+			return
+		}
+		defer close(call.done)
+	}
+	t := &a2a.Task{TaskID: taskID, Status: a2a.StatusSucceeded, TaskType: ct.TaskType, AgentID: c.cfg.AgentID, ContextID: ct.ContextID}
+	async, follow := false, false
+	if !slices.Contains(c.cfg.TaskTypes, ct.TaskType) {
+		t.Status, t.Error = a2a.StatusFailed, a2a.NewError(a2a.ErrValidationFailed, "unsupported task_type")
+	} else if async = c.async(ct.TaskType); async {
+		t.Status = a2a.StatusPending // 완료는 complete()가 콜백으로
+	} else if result, err := c.run(ct, t); err != nil {
+		t.Status, t.Error = a2a.StatusFailed, err
+	} else {
+		t.Result, _ = json.Marshal(result)
+		if follow = ct.TaskType == "TRACK" && ct.ReplyURL != ""; follow {
+			t.Status = a2a.StatusRunning // 체크포인트는 follow()가 이벤트로
+		}
+	}
+	c.mu.Lock()
+	c.m[taskID] = t
+	c.mu.Unlock()
+	switch {
+	case async:
+		go c.complete(ct, taskID)
+		w.WriteHeader(202)
+	case follow:
+		go c.follow(ct, taskID)
+		w.WriteHeader(202)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"task_id": taskID, "status": t.Status})
+}
+
+// idemCall: idempotency_key로 예약된 task. done은 task가 저장되면 닫힌다.
+type idemCall struct {
+	taskID string
+	req    string // fingerprint — 같은 키의 재요청인지 확인
+	done   chan struct{}
+}
+
+// fingerprint: task_type + 공백을 뺀 input(reply_url 등 전달 정보는 재시도마다 달라질 수 있어 제외)
+func fingerprint(ct a2a.CreateTask) string {
+	var b bytes.Buffer
+	if json.Compact(&b, ct.Input) != nil {
+		b.Reset()
+		b.Write(ct.Input)
+	}
+	return ct.TaskType + "\x00" + b.String()
+}
+
+// update: 저장된 task 사본을 고쳐 교체(조회 중인 사본과 겹치지 않게)
+func (c *carrier) update(id string, fn func(*a2a.Task)) *a2a.Task {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.m[id]
+	if !ok {
+		return nil
+	}
+	cp := *t
+	fn(&cp)
+	c.m[id] = &cp
+	return &cp
+}
+
+// run: task_type별 처리(동기). t.Artifacts는 여기서 채운다.
+func (c *carrier) run(ct a2a.CreateTask, t *a2a.Task) (any, *a2a.ErrorPayload) {
+	switch ct.TaskType {
+	case "QUOTE":
+		req, err := ratecard.ParseRequest(ct.Input)
+		if err != nil {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+		}
+		qs, err := c.card.Quote(req)
+		if err != nil {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+		}
+		// 대표 견적(우선 처리면 가장 빠른 것) + 서비스별 대안
+		return struct {
+			ratecard.Quote
+			Alternatives []ratecard.Quote `json:"alternatives"`
+		}{ratecard.Best(qs, req.Priority), qs}, nil
+	case "SHIP":
+		trackingID := a2a.NewID(c.cfg.trackingPrefix(c.card.Carrier))
+		l, labels, err := c.shipLabels(trackingID, ct.Input)
+		if err != nil {
+			return nil, a2a.NewError(a2a.ErrInternal, err.Error())
+		}
+		t.Artifacts = labels
+		sh := c.register(l, c.etaDays(ct.Input, l.Service))
+		return map[string]any{
+			"status": "READY", "tracking_id": trackingID, "label_url": c.artifacts.Sign(labels[0]).URL,
+			"estimated_delivery": sh.eta,
+		}, nil
+	case "VOID_SHIPMENT":
+		var in struct {
+			TrackingID string `json:"tracking_id"`
+		}
+		if err := json.Unmarshal(ct.Input, &in); err != nil || in.TrackingID == "" {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, "tracking_id is required")
+		}
+		if err := c.void(in.TrackingID); err != nil {
+			return nil, err
+		}
+		return map[string]any{"status": "VOIDED", "tracking_id": in.TrackingID}, nil
+	case "TRACK":
+		sh, err := c.shipment(ct.Input)
+		if err != nil {
+			return nil, err
+		}
+		return c.snapshot(sh), nil
+	}
+	return nil, a2a.NewError(a2a.ErrValidationFailed, "unsupported task_type")
+}
+
+func (c *carrier) getTask(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	c.mu.Lock()
+	t, ok := c.m[id]
+	c.mu.Unlock()
+	if !ok {
+		w.WriteHeader(404)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
+		return
+	}
+	// update는 포인터를 교체만 하므로 잠금 밖에서 복사해도 안전
+	cp := *t
+	cp.Artifacts = c.artifacts.SignAll(t.Artifacts) // 조회할 때마다 새로 서명
+	json.NewEncoder(w).Encode(&cp)
+}
+
+// shipLabels: SHIP 입력으로 라벨(PDF, PNG)을 만들어 저장 — [0]이 PDF
+func (c *carrier) shipLabels(trackingID string, input json.RawMessage) (label.Label, []a2a.Artifact, error) {
+	l, err := label.FromShipInput(c.card.Carrier, trackingID, input)
+	if err != nil {
+		return l, nil, err
+	}
+	pdf, err := l.PDF()
+	if err != nil {
+		return l, nil, err
+	}
+	img, err := l.PNG()
+	if err != nil {
+		return l, nil, err
+	}
+	a, err := c.artifacts.PutBytes(trackingID+".pdf", "application/pdf", pdf)
+	if err != nil {
+		return l, nil, err
+	}
+	b, err := c.artifacts.PutBytes(trackingID+".png", "image/png", img)
+	if err != nil {
+		return l, nil, err
+	}
+	return l, []a2a.Artifact{a, b}, nil
+}
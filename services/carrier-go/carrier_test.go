@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+)
+
+// newTestCarrier: carriers/agent-a.yaml 기반, 지연/실패 없이 임시 디렉터리에 저장
+func newTestCarrier(t *testing.T, edit func(*Config)) (*carrier, string) {
+	t.Helper()
+	cfg, err := loadConfig("carriers/agent-a.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.ArtifactDir, cfg.Seed = t.TempDir(), 1
+	if edit != nil {
+		edit(cfg)
+	}
+	if err := cfg.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := newCarrier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.log = log.New(io.Discard, "", 0)
+	srv := httptest.NewServer(c.routes())
+	t.Cleanup(srv.Close)
+	return c, srv.URL
+}
+
+func postTask(t *testing.T, url string, ct a2a.CreateTask) (int, map[string]any) {
+	t.Helper()
+	b, _ := json.Marshal(ct)
+	resp, err := http.Post(url+"/tasks", "application/json", bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out map[string]any
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func (c *carrier) shipmentCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.shipments)
+}
+
+const shipInput = `{"shipment":{"from":{"country":"KR","city":"Seoul"},"to":{"country":"US","city":"LA"},"parcel":{"weight_kg":1}}}`
+
+func TestCreateTaskIdempotency(t *testing.T) {
+	c, url := newTestCarrier(t, nil)
+	ship := a2a.CreateTask{TaskType: "SHIP", Input: json.RawMessage(shipInput), IdempotencyKey: "k-1"}
+
+	// 동시 재시도도 같은 task 하나로 — 라벨은 한 번만 만든다
+	var wg sync.WaitGroup
+	ids := make([]string, 8)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, out := postTask(t, url, ship)
+			ids[i], _ = out["task_id"].(string)
+		}()
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id == "" || id != ids[0] {
+			t.Fatalf("task ids = %v", ids)
+		}
+	}
+	if n := c.shipmentCount(); n != 1 {
+		t.Errorf("shipments = %d, want 1", n)
+	}
+
+	tests := []struct {
+		name   string
+		ct     a2a.CreateTask
+		status int
+		same   bool
+	}{
+		{"replay with reformatted input", a2a.CreateTask{TaskType: "SHIP", IdempotencyKey: "k-1", ReplyURL: "http://localhost:1/events",
+			Input: json.RawMessage(strings.ReplaceAll(shipInput, ":", ": "))}, 200, true},
+		{"different input", a2a.CreateTask{TaskType: "SHIP", IdempotencyKey: "k-1", Input: json.RawMessage(`{"shipment":{"parcel":{"weight_kg":2}}}`)}, 409, false},
+		{"different task_type", a2a.CreateTask{TaskType: "QUOTE", IdempotencyKey: "k-1", Input: json.RawMessage(shipInput)}, 409, false},
+		{"other key", a2a.CreateTask{TaskType: "SHIP", IdempotencyKey: "k-2", Input: json.RawMessage(shipInput)}, 200, false},
+	}
+	for _, tt := range tests {
+		status, out := postTask(t, url, tt.ct)
+		if status != tt.status || (out["task_id"] == ids[0]) != tt.same {
+			t.Errorf("%s: %d %v", tt.name, status, out)
+		}
+		if tt.status == 409 && out["code"] != a2a.ErrConflict {
+			t.Errorf("%s: body = %v", tt.name, out)
+		}
+	}
+	if n := c.shipmentCount(); n != 2 {
+		t.Errorf("shipments = %d, want 2", n)
+	}
+}
+
+// 처리 중인 키의 재요청은 호출자가 먼저 포기하면 CONFLICT
+func TestCreateTaskIdempotencyInProgress(t *testing.T) {
+	c, url := newTestCarrier(t, nil)
+	ct := a2a.CreateTask{TaskType: "SHIP", Input: json.RawMessage(shipInput), IdempotencyKey: "k-busy"}
+	c.keys[ct.IdempotencyKey] = &idemCall{taskID: "t_busy", req: fingerprint(ct), done: make(chan struct{})}
+
+	b, _ := json.Marshal(ct)
+	cl := &http.Client{Timeout: 100 * time.Millisecond}
+	if resp, err := cl.Post(url+"/tasks", "application/json", bytes.NewReader(b)); err == nil {
+		resp.Body.Close()
+		t.Fatalf("status %d, want the caller to time out", resp.StatusCode)
+	}
+	c.mu.Lock()
+	_, ran := c.m["t_busy"]
+	c.mu.Unlock()
+	if ran || c.shipmentCount() != 0 {
+		t.Error("request ran while the key was reserved")
+	}
+}
+
+func TestConfigNormalize(t *testing.T) {
+	base := func() Config { return Config{AgentID: "x", Port: 1, RateCard: "card.yaml", dir: "carriers"} }
+	tests := []struct {
+		name string
+		edit func(*Config)
+		want string
+	}{
+		{"agent_id", func(c *Config) { c.AgentID = "" }, "agent_id is required"},
+		{"port", func(c *Config) { c.Port = 0 }, "port is required"},
+		{"rate_card", func(c *Config) { c.RateCard = "" }, "rate_card is required"},
+		{"rates over 1", func(c *Config) { c.Failure = Failure{Rate: 0.5, Drop: 0.3, DropAfter: 0.3} }, "sum to at most 1"},
+		{"negative drop_after", func(c *Config) { c.Failure.DropAfter = -0.1 }, "must be >= 0"},
+		{"negative code", func(c *Config) { c.Failure.Codes = map[string]float64{"TIMEOUT": -1} }, "failure.codes.TIMEOUT"},
+		{"latency", func(c *Config) { c.Latency = Dist{Dist: "pareto"} }, "latency: unknown dist"},
+		{"async delay", func(c *Config) { c.Async.Delay = Dist{Dist: "normal"} }, "async.delay: normal needs mean"},
+		{"exception rate", func(c *Config) { c.Tracking.ExceptionRate = 2 }, "exception_rate"},
+	}
+	for _, tt := range tests {
+		c := base()
+		tt.edit(&c)
+		if err := c.normalize(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	c := base()
+	c.Failure = Failure{Rate: 0.1, Codes: map[string]float64{a2a.ErrUnavailable: 0.1}}
+	if err := c.normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Failure.Codes[a2a.ErrUnavailable] != 0.2 || c.Tracking.Day != time.Minute || c.PublicURL != "http://localhost:1" ||
+		c.RateCard != filepath.Join("carriers", "card.yaml") || len(c.TaskTypes) != len(defaultTaskTypes) {
+		t.Errorf("defaults = %+v", c)
+	}
+}
+
+func TestTrackingPrefix(t *testing.T) {
+	tests := []struct{ prefix, carrier, want string }{
+		{"Z-", "AgentA", "Z-"},
+		{"", "agent b", "A-"},
+		{"", "한국Post", "P-"},
+		{"", "7Days", "7-"},
+		{"", "택배", "T-"},
+	}
+	for _, tt := range tests {
+		if got := (&Config{TrackingPrefix: tt.prefix}).trackingPrefix(tt.carrier); got != tt.want {
+			t.Errorf("trackingPrefix(%q, %q) = %q, want %q", tt.prefix, tt.carrier, got, tt.want)
+		}
+	}
+}
+
+func TestLoadCompose(t *testing.T) {
+	cfgs, err := loadCompose("compose.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ports []int
+	for _, c := range cfgs {
+		ports = append(ports, c.Port)
+		if _, err := os.Stat(c.RateCard); err != nil {
+			t.Errorf("%s: rate card: %v", c.AgentID, err)
+		}
+	}
+	if len(cfgs) != 3 || ports[0] != 8081 || ports[1] != 8082 || ports[2] != 8084 {
+		t.Errorf("ports = %v", ports)
+	}
+
+	dir := t.TempDir()
+	abs, _ := filepath.Abs("carriers/agent-a.yaml")
+	write := func(body string) string {
+		p := filepath.Join(dir, "compose.yaml")
+		os.WriteFile(p, []byte(body), 0o644)
+		return p
+	}
+	cfgs, err = loadCompose(write("carriers:\n  - file: " + abs + "\n  - {file: " + abs + ", agent_id: carrier.a2, port: 8091, seed: 7}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a2 := cfgs[1]; a2.AgentID != "carrier.a2" || a2.Port != 8091 || a2.Seed != 7 || a2.Carrier != "AgentA" || a2.PublicURL != "http://localhost:8091" {
+		t.Errorf("override = %+v", a2)
+	}
+
+	for body, want := range map[string]string{
+		"carriers: []\n":                                     "no carriers",
+		"carriers:\n  - {port: 1}\n":                         "file is required",
+		"carriers:\n  - file: " + abs + "\n  - file: " + abs: "port 8081 used by both",
+		"carriers:\n  - {file: " + abs + ", port: x}\n":      "carriers[0]",
+	} {
+		if _, err := loadCompose(write(body)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: err = %v, want %q", body, err, want)
+		}
+	}
+}
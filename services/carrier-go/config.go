@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	a2a "a2a/contract"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config: 캐리어 에이전트 하나의 정체성/포트/제공 서비스/요율표/지연·실패 동작 (carriers/*.yaml)
+type Config struct {
+	AgentID        string   `yaml:"agent_id"`
+	Name           string   `yaml:"name"`
+	Carrier        string   `yaml:"carrier"` // 견적/라벨에 찍히는 이름(요율표 carrier보다 우선)
+	Port           int      `yaml:"port"`
+	PublicURL      string   `yaml:"public_url"`      // 기본 http://localhost:<port>
+	TrackingPrefix string   `yaml:"tracking_prefix"` // 기본 carrier 첫 글자 + "-"
+	TaskTypes      []string `yaml:"task_types"`      // 기본 QUOTE, SHIP, VOID_SHIPMENT, TRACK
+	Services       []string `yaml:"services"`        // 요율표 서비스 등급 중 제공할 것(비면 전부)
+	RateCard       string   `yaml:"rate_card"`       // 설정 파일 기준 상대 경로
+	ArtifactDir    string   `yaml:"artifact_dir"`    // 기본 $TMP/<agent_id>-artifacts
+	Latency        Dist     `yaml:"latency"`         // /tasks 요청마다 응답 전 지연
+	Failure        Failure  `yaml:"failure"`
+	Async          Async    `yaml:"async"`
+	Tracking       Tracking `yaml:"tracking"`
+	Seed           uint64   `yaml:"seed"` // 지연/실패 난수 시드(0이면 무작위 — 시작 로그의 값으로 재현)
+
+	dir string // 설정 파일 위치(상대 경로 기준)
+}
+
+// Dist: 지연 분포 — fixed | uniform | normal | lognormal | exponential.
+// dist를 생략하면 max가 있으면 uniform, 아니면 fixed(mean 또는 min). 결과는 [min, max]로 자른다(max 0은 상한 없음).
+type Dist struct {
+	Dist   string        `yaml:"dist"`
+	Min    time.Duration `yaml:"min"`
+	Max    time.Duration `yaml:"max"`
+	Mean   time.Duration `yaml:"mean"`
+	StdDev time.Duration `yaml:"stddev"`
+}
+
+// Failure: /tasks 요청을 오류 코드별 비율로 거절하거나(codes), 응답 없이 연결을 끊는다.
+// drop은 처리 전에, drop_after는 처리한 뒤 응답만 버리고 끊는다(요청은 반영됐지만 호출자는 모름 —
+// idempotency_key 재시도 확인용). rate/code는 codes에 한 줄 쓴 것과 같다(code 기본 UNAVAILABLE).
+type Failure struct {
+	Rate      float64            `yaml:"rate"`
+	Code      string             `yaml:"code"`
+	Codes     map[string]float64 `yaml:"codes"`
+	Drop      float64            `yaml:"drop"`
+	DropAfter float64            `yaml:"drop_after"`
+}
+
+// Async: task를 PENDING으로 받고 delay 뒤 완료 — 결과는 reply_url 콜백(TASK_COMPLETED/FAILED)과 GET /tasks/{id}
+type Async struct {
+	Enabled   bool     `yaml:"enabled"`
+	TaskTypes []string `yaml:"task_types"` // 비면 전부
+	Delay     Dist     `yaml:"delay"`
+}
+
+// Tracking: TRACK 시뮬레이션 — 운송 하루를 day(실제 시간)로 압축, exception_rate 비율로 배송 사고(EXCEPTION)
+type Tracking struct {
+	Day           time.Duration `yaml:"day"` // 기본 1m
+	ExceptionRate float64       `yaml:"exception_rate"`
+}
+
+var defaultTaskTypes = []string{"QUOTE", "SHIP", "VOID_SHIPMENT", "TRACK"}
+
+func loadConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{dir: filepath.Dir(path)}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// normalize: 기본값 채우기 + 검사
+func (c *Config) normalize() error {
+	switch {
+	case c.AgentID == "":
+		return fmt.Errorf("agent_id is required")
+	case c.Port <= 0:
+		return fmt.Errorf("%s: port is required", c.AgentID)
+	case c.RateCard == "":
+		return fmt.Errorf("%s: rate_card is required", c.AgentID)
+	}
+	if c.Failure.Rate > 0 {
+		if c.Failure.Code == "" {
+			c.Failure.Code = a2a.ErrUnavailable
+		}
+		if c.Failure.Codes == nil {
+			c.Failure.Codes = map[string]float64{}
+		}
+		c.Failure.Codes[c.Failure.Code] += c.Failure.Rate
+	}
+	total := c.Failure.Drop + c.Failure.DropAfter
+	for code, p := range c.Failure.Codes {
+		if p < 0 {
+			return fmt.Errorf("%s: failure.codes.%s must be >= 0", c.AgentID, code)
+		}
+		total += p
+	}
+	if c.Failure.Drop < 0 || c.Failure.DropAfter < 0 || total > 1 {
+		return fmt.Errorf("%s: failure rates must be >= 0 and sum to at most 1", c.AgentID)
+	}
+	if err := c.Latency.validate(); err != nil {
+		return fmt.Errorf("%s: latency: %w", c.AgentID, err)
+	}
+	if err := c.Async.Delay.validate(); err != nil {
+		return fmt.Errorf("%s: async.delay: %w", c.AgentID, err)
+	}
+	if c.Tracking.Day < 0 || c.Tracking.ExceptionRate < 0 || c.Tracking.ExceptionRate > 1 {
+		return fmt.Errorf("%s: tracking.day must be >= 0 and exception_rate in [0, 1]", c.AgentID)
+	}
+	if c.Tracking.Day == 0 {
+		c.Tracking.Day = time.Minute
+	}
+	if c.Name == "" {
+		c.Name = c.AgentID
+	}
+	if c.PublicURL == "" {
+		c.PublicURL = fmt.Sprintf("http://localhost:%d", c.Port)
+	}
+	if len(c.TaskTypes) == 0 {
+		c.TaskTypes = defaultTaskTypes
+	}
+	if c.ArtifactDir == "" {
+		c.ArtifactDir = filepath.Join(os.TempDir(), c.AgentID+"-artifacts")
+	}
+	c.RateCard = c.path(c.RateCard)
+	return nil
+}
+
+func (c *Config) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
+
+func (c *Config) trackingPrefix(carrier string) string {
+	if c.TrackingPrefix != "" {
+		return c.TrackingPrefix
+	}
+	// 캐리어 이름의 첫 영숫자(룬 단위) — 트래킹 번호는 Code128(ASCII)로 찍히므로 한글 등은 건너뛴다
+	for _, r := range strings.ToUpper(carrier) {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return string(r) + "-"
+		}
+	}
+	return "T-"
+}
+
+// ---- compose: 여러 캐리어를 한 프로세스로 -------------------------------------------
+
+// loadCompose: carriers 항목마다 file(캐리어 설정)을 읽고, 같은 항목의 나머지 키로 덮어쓴다.
+//
+//	carriers:
+//	  - file: carriers/agent-a.yaml
+//	  - {file: carriers/agent-a.yaml, agent_id: carrier.agent-a2, port: 8091}
+func loadCompose(path string) ([]*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		Carriers []yaml.Node `yaml:"carriers"`
+	}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var out []*Config
+	ports := map[int]string{}
+	for i := range doc.Carriers {
+		n := &doc.Carriers[i]
+		var ref struct {
+			File string `yaml:"file"`
+		}
+		if err := n.Decode(&ref); err != nil || ref.File == "" {
+			return nil, fmt.Errorf("%s: carriers[%d]: file is required", path, i)
+		}
+		file := ref.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(filepath.Dir(path), file)
+		}
+		cfg, err := loadConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("%s: carriers[%d]: %w", path, i, err)
+		}
+		if err := cfg.normalize(); err != nil {
+			return nil, err
+		}
+		if prev, dup := ports[cfg.Port]; dup {
+			return nil, fmt.Errorf("%s: port %d used by both %s and %s", path, cfg.Port, prev, cfg.AgentID)
+		}
+		ports[cfg.Port] = cfg.AgentID
+		out = append(out, cfg)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%s: no carriers", path)
+	}
+	return out, nil
+}
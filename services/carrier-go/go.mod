@@ -0,0 +1,8 @@
+module a2a/carrier
+
+go 1.24.2
+
+require (
+	github.com/go-chi/chi/v5 v5.2.3 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// 캐리어 에이전트 — 정체성/포트/서비스/요율표/지연·실패 동작은 설정 파일에서.
+//
+//	go run . -config carriers/agent-a.yaml     # 하나
+//	go run . -compose compose.yaml             # 여러 캐리어(로컬 E2E)
+func main() {
+	config := flag.String("config", env("CARRIER_CONFIG", "carriers/agent-a.yaml"), "carrier config file")
+	compose := flag.String("compose", env("CARRIER_COMPOSE", ""), "compose file listing carrier configs")
+	seed := flag.Uint64("seed", 0, "base seed for carriers without one (i-th carrier gets seed+i)")
+	flag.Parse()
+
+	var cfgs []*Config
+	if *compose != "" {
+		var err error
+		if cfgs, err = loadCompose(*compose); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		cfg, err := loadConfig(*config)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := cfg.normalize(); err != nil {
+			log.Fatal(err)
+		}
+		cfgs = []*Config{cfg}
+	}
+
+	var urls []string
+	errc := make(chan error, len(cfgs))
+	for i, cfg := range cfgs {
+		if cfg.Seed == 0 && *seed != 0 {
+			cfg.Seed = *seed + uint64(i)
+		}
+		c, err := newCarrier(cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		urls = append(urls, cfg.PublicURL)
+		go func() {
+			c.log.Printf("%s (%s) listening :%d seed=%d", cfg.Name, c.card.Carrier, cfg.Port, cfg.Seed)
+			errc <- fmt.Errorf("%s: %w", cfg.AgentID, http.ListenAndServe(fmt.Sprintf(":%d", cfg.Port), c.routes()))
+		}()
+	}
+	if len(cfgs) > 1 {
+		log.Printf("CARRIER_URLS=%s", strings.Join(urls, ","))
+	}
+	log.Fatal(<-errc) // 하나라도 죽으면 전체 종료
+}
+
+func env(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
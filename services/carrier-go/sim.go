@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	a2a "a2a/contract"
+)
+
+// ====== 시뮬레이션: 지연 분포, 오류 코드별 실패, 연결 끊김 ======
+//
+// 난수는 캐리어마다 시드 고정 PCG 하나에서 뽑는다. 같은 시드 + 같은 요청 순서면 같은 지연/실패가 나온다.
+
+type rng struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newRNG(seed uint64) *rng {
+	return &rng{r: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+func (g *rng) float() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.r.Float64()
+}
+
+func (g *rng) norm() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.r.NormFloat64()
+}
+
+func (g *rng) exp() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.r.ExpFloat64()
+}
+
+func (d Dist) kind() string {
+	switch {
+	case d.Dist != "":
+		return d.Dist
+	case d.Max > 0:
+		return "uniform"
+	}
+	return "fixed"
+}
+
+func (d Dist) validate() error {
+	if d.Min < 0 || d.Max < 0 || d.Mean < 0 || d.StdDev < 0 {
+		return fmt.Errorf("durations must be >= 0")
+	}
+	if d.Max > 0 && d.Max < d.Min {
+		return fmt.Errorf("max < min")
+	}
+	switch d.kind() {
+	case "fixed", "exponential":
+	case "uniform":
+		if d.Max == 0 {
+			return fmt.Errorf("uniform needs max")
+		}
+	case "normal", "lognormal":
+		if d.Mean == 0 {
+			return fmt.Errorf("%s needs mean", d.kind())
+		}
+	default:
+		return fmt.Errorf("unknown dist %q", d.Dist)
+	}
+	return nil
+}
+
+// sample: 분포에서 지연 하나
+func (d Dist) sample(g *rng) time.Duration {
+	var v float64
+	switch d.kind() {
+	case "fixed":
+		v = float64(max(d.Mean, d.Min))
+	case "uniform":
+		v = float64(d.Min) + g.float()*float64(d.Max-d.Min)
+	case "normal":
+		v = float64(d.Mean) + g.norm()*float64(d.StdDev)
+	case "lognormal":
+		// mean/stddev가 로그정규분포 자체의 평균/표준편차가 되도록 μ, σ 환산
+		m, s := float64(d.Mean), float64(d.StdDev)
+		sigma2 := math.Log1p(s * s / (m * m))
+		v = math.Exp(math.Log(m) - sigma2/2 + math.Sqrt(sigma2)*g.norm())
+	case "exponential":
+		v = float64(d.Min) + g.exp()*float64(max(d.Mean-d.Min, 0))
+	}
+	v = max(v, float64(d.Min))
+	if d.Max > 0 {
+		v = min(v, float64(d.Max))
+	}
+	return time.Duration(v)
+}
+
+// 오류 코드 → HTTP 상태(클라이언트 재시도 분류와 맞춤)
+var failureStatus = map[string]int{
+	a2a.ErrUnavailable:      503,
+	a2a.ErrTimeout:          504,
+	a2a.ErrInternal:         500,
+	a2a.ErrConflict:         409,
+	a2a.ErrValidationFailed: 400,
+}
+
+// outcome: 이번 요청의 운명 — "" 정상, "DROP" 처리 전 끊기, "DROP_AFTER" 처리 후 응답 유실, 그 외는 오류 코드
+func (c *carrier) outcome() string {
+	u := c.rng.float()
+	if u < c.cfg.Failure.Drop {
+		return "DROP"
+	}
+	u -= c.cfg.Failure.Drop
+	if u < c.cfg.Failure.DropAfter {
+		return "DROP_AFTER"
+	}
+	u -= c.cfg.Failure.DropAfter
+	codes := make([]string, 0, len(c.cfg.Failure.Codes))
+	for code := range c.cfg.Failure.Codes {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes) // map 순회 순서와 무관하게 재현
+	for _, code := range codes {
+		if u < c.cfg.Failure.Codes[code] {
+			return code
+		}
+		u -= c.cfg.Failure.Codes[code]
+	}
+	return ""
+}
+
+// simulate: 지연 후 설정된 비율로 오류 응답 또는 연결 끊기(처리 전/후)
+func (c *carrier) simulate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d := c.cfg.Latency.sample(c.rng); d > 0 {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+				return // 호출자가 이미 포기
+			}
+		}
+		switch code := c.outcome(); code {
+		case "":
+			next.ServeHTTP(w, r)
+		case "DROP":
+			c.log.Printf("simulated drop %s %s", r.Method, r.URL.Path)
+			panic(http.ErrAbortHandler) // 응답 없이 연결 종료(서버가 로그 없이 처리)
+		case "DROP_AFTER":
+			next.ServeHTTP(discard{}, r)
+			c.log.Printf("simulated lost response %s %s", r.Method, r.URL.Path)
+			panic(http.ErrAbortHandler)
+		default:
+			status, ok := failureStatus[code]
+			if !ok {
+				status = 500
+			}
+			c.log.Printf("simulated failure %s %s → %s", r.Method, r.URL.Path, code)
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(a2a.NewError(code, "simulated failure"))
+		}
+	})
+}
+
+// discard: 처리 결과를 버리는 ResponseWriter(DROP_AFTER)
+type discard struct{}
+
+func (discard) Header() http.Header         { return http.Header{} }
+func (discard) Write(b []byte) (int, error) { return len(b), nil }
+func (discard) WriteHeader(int)             {}
+
+// ====== 비동기 모드 ======
+
+func (c *carrier) async(taskType string) bool {
+	return c.cfg.Async.Enabled && (len(c.cfg.Async.TaskTypes) == 0 || slices.Contains(c.cfg.Async.TaskTypes, taskType))
+}
+
+// complete: async.delay 뒤 task를 실행해 저장하고 reply_url로 완료/실패 이벤트 전송
+func (c *carrier) complete(ct a2a.CreateTask, taskID string) {
+	time.Sleep(c.cfg.Async.Delay.sample(c.rng))
+	c.update(taskID, func(t *a2a.Task) { t.Status = a2a.StatusRunning })
+
+	var (
+		out a2a.Task // run이 채우는 Artifacts 받기(라벨 생성은 잠금 밖에서)
+		ev  a2a.Event
+	)
+	result, err := c.run(ct, &out)
+	t := c.update(taskID, func(t *a2a.Task) {
+		if err != nil {
+			t.Status, t.Error = a2a.StatusFailed, err
+			ev = a2a.NewFailedEvent(taskID, err)
+			return
+		}
+		t.Status, t.Artifacts = a2a.StatusSucceeded, out.Artifacts
+		t.Result, _ = json.Marshal(result)
+		ev = a2a.NewCompletedEvent(taskID, t.Result)
+	})
+	if ct.ReplyURL == "" || t == nil {
+		return // 호출자는 폴링
+	}
+	c.notify(ct.ReplyURL, ev)
+}
+
+// notify: seq를 붙여 reply_url로 서명된 이벤트 전송(실패는 로그만)
+func (c *carrier) notify(replyURL string, ev a2a.Event) {
+	ev = c.events.Append(ev)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := a2a.PostEvent(ctx, replyURL, ev, c.signer); err != nil {
+		c.log.Println("callback failed:", ev.TaskID, ev.Event, err)
+	}
+}
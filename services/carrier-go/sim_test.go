@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func simCarrier(seed uint64, f Failure) *carrier {
+	cfg := &Config{
+		Latency: Dist{Dist: "lognormal", Mean: 250 * time.Millisecond, StdDev: 200 * time.Millisecond, Max: 1200 * time.Millisecond},
+		Failure: f,
+		Seed:    seed,
+	}
+	return &carrier{cfg: cfg, rng: newRNG(seed), log: log.New(io.Discard, "", 0)}
+}
+
+type simDraw struct {
+	latency time.Duration
+	outcome string
+}
+
+func draws(c *carrier, n int) []simDraw {
+	out := make([]simDraw, n)
+	for i := range out {
+		out[i] = simDraw{c.cfg.Latency.sample(c.rng), c.outcome()} // simulate와 같은 순서
+	}
+	return out
+}
+
+// 같은 시드면 같은 지연/오류/끊김 순서
+func TestSimulationReproducibleBySeed(t *testing.T) {
+	f := Failure{Codes: map[string]float64{"UNAVAILABLE": 0.1, "TIMEOUT": 0.05, "INTERNAL": 0.02}, Drop: 0.03, DropAfter: 0.02}
+	a, b := draws(simCarrier(42, f), 2000), draws(simCarrier(42, f), 2000)
+	seen := map[string]int{}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("draw %d differs: %+v vs %+v", i, a[i], b[i])
+		}
+		if a[i].latency < 0 || a[i].latency > 1200*time.Millisecond {
+			t.Errorf("draw %d latency %v outside [0, 1200ms]", i, a[i].latency)
+		}
+		seen[a[i].outcome]++
+	}
+	for _, o := range []string{"", "DROP", "DROP_AFTER", "UNAVAILABLE", "TIMEOUT", "INTERNAL"} {
+		if seen[o] == 0 {
+			t.Errorf("outcome %q never drawn in 2000 requests: %v", o, seen)
+		}
+	}
+	if c := draws(simCarrier(43, f), 2000); c[0] == a[0] && c[1] == a[1] && c[2] == a[2] {
+		t.Error("different seed gave the same sequence")
+	}
+}
+
+// drop_after: 요청은 처리하고 응답만 버린 채 연결을 끊는다
+func TestSimulateDropAfterProcessing(t *testing.T) {
+	tests := []struct {
+		name    string
+		f       Failure
+		handled int32
+	}{
+		{"drop before handler", Failure{Drop: 1}, 0},
+		{"drop after handler", Failure{DropAfter: 1}, 1},
+	}
+	for _, tt := range tests {
+		c := simCarrier(1, tt.f)
+		c.cfg.Latency = Dist{}
+		var handled atomic.Int32
+		srv := httptest.NewServer(c.simulate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled.Add(1)
+			w.Write([]byte(`{"task_id":"t_1"}`))
+		})))
+		srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+		resp, err := http.Post(srv.URL+"/tasks", "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: got response %d, want connection error", tt.name, resp.StatusCode)
+		}
+		srv.Close()
+		if got := handled.Load(); got != tt.handled {
+			t.Errorf("%s: handler ran %d times, want %d", tt.name, got, tt.handled)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	a2a "a2a/contract"
+	"a2a/label"
+	"a2a/ratecard"
+)
+
+// ====== 배송 추적(TRACK) ======
+//
+// SHIP 때 체크포인트 계획(상태 + 예정 시각)을 정해 두고, 현재 상태는 시계로 계산한다(예정 시각이 지난 것까지).
+// 시각은 운송 일수 × tracking.day — 기본 1m이면 3일 배송이 3분 만에 끝난다.
+
+// shipment: tracking_id 하나의 추적 계획. plan/voided는 c.mu로 보호.
+type shipment struct {
+	trackingID string
+	service    string
+	eta        time.Time
+	plan       []a2a.Checkpoint
+	voided     bool
+	stop       chan struct{} // VOID_SHIPMENT로 닫힘 — 구독(follow) 중단
+}
+
+// 사고 사유(EXCEPTION 메시지)
+var exceptionReasons = []string{
+	"address not found",
+	"held at customs",
+	"damaged in transit",
+	"recipient unavailable",
+}
+
+// register: 라벨 정보로 추적 계획을 만들어 등록. 사고 여부/시점은 시드 난수로 정한다.
+func (c *carrier) register(l label.Label, etaDays int) *shipment {
+	start := time.Now().UTC()
+	at := func(days float64) time.Time { return start.Add(time.Duration(days * float64(c.cfg.Tracking.Day))) }
+	origin, dest := place(l.From), place(l.To)
+	eta := float64(etaDays)
+	plan := []a2a.Checkpoint{
+		{Status: a2a.ShipmentLabelCreated, At: at(0), Location: origin, Message: "shipping label created"},
+		{Status: a2a.ShipmentPickedUp, At: at(0.25), Location: origin, Message: "picked up by courier"},
+		{Status: a2a.ShipmentInTransit, At: at(0.5), Location: origin, Message: "departed origin facility"},
+		{Status: a2a.ShipmentInTransit, At: at(max(eta-0.7, 0.6)), Location: dest, Message: "arrived at destination facility"},
+		{Status: a2a.ShipmentOutForDelivery, At: at(max(eta-0.3, 0.7)), Location: dest, Message: "out for delivery"},
+		{Status: a2a.ShipmentDelivered, At: at(eta), Location: dest, Message: "delivered"},
+	}
+	if c.cfg.Tracking.ExceptionRate > 0 && c.rng.float() < c.cfg.Tracking.ExceptionRate {
+		// 라벨 생성 이후 어느 단계를 사고로 대체하고 거기서 끝
+		i := 1 + int(c.rng.float()*float64(len(plan)-1))
+		reason := exceptionReasons[int(c.rng.float()*float64(len(exceptionReasons)))]
+		plan[i] = a2a.Checkpoint{Status: a2a.ShipmentException, At: plan[i].At, Location: plan[i].Location, Message: reason}
+		plan = plan[:i+1]
+	}
+	// 계획은 상태 기계(a2a.CanAdvance)를 따라야 한다 — 어긋나면 거기서 자른다
+	if valid, ok := checkPlan(plan); !ok {
+		c.log.Printf("tracking plan %s: illegal %s at checkpoint %d, truncated", l.TrackingID, plan[len(valid)].Status, len(valid))
+		plan = valid
+	}
+	sh := &shipment{trackingID: l.TrackingID, service: l.Service, eta: at(eta), plan: plan, stop: make(chan struct{})}
+	c.mu.Lock()
+	c.shipments[sh.trackingID] = sh
+	c.mu.Unlock()
+	return sh
+}
+
+// checkPlan: 상태 기계를 따르는 앞부분과 전체가 따르는지 여부
+func checkPlan(plan []a2a.Checkpoint) ([]a2a.Checkpoint, bool) {
+	var last a2a.ShipmentStatus
+	for i, cp := range plan {
+		if !a2a.CanAdvance(last, cp.Status) {
+			return plan[:i], false
+		}
+		last = cp.Status
+	}
+	return plan, true
+}
+
+func place(a label.Address) string {
+	if a.City == "" {
+		return a.Country
+	}
+	return strings.TrimSuffix(a.City+", "+a.Country, ", ")
+}
+
+// etaDays: 선택한 견적의 eta_days, 없으면 요율표에서 같은 서비스 ETA(그것도 없으면 3일)
+func (c *carrier) etaDays(input json.RawMessage, service string) int {
+	var in struct {
+		Quote struct {
+			EtaDays int `json:"eta_days"`
+		} `json:"quote"`
+		Shipment json.RawMessage `json:"shipment"`
+	}
+	_ = json.Unmarshal(input, &in)
+	if in.Quote.EtaDays > 0 {
+		return in.Quote.EtaDays
+	}
+	if len(in.Shipment) > 0 {
+		input = in.Shipment
+	}
+	if req, err := ratecard.ParseRequest(input); err == nil {
+		if qs, err := c.card.Quote(req); err == nil {
+			for _, q := range qs {
+				if q.Service == service {
+					return q.EtaDays
+				}
+			}
+			return qs[0].EtaDays
+		}
+	}
+	return 3
+}
+
+// shipment: TRACK/VOID 입력의 tracking_id로 조회
+func (c *carrier) shipment(input json.RawMessage) (*shipment, *a2a.ErrorPayload) {
+	var in struct {
+		TrackingID string `json:"tracking_id"`
+	}
+	if err := json.Unmarshal(input, &in); err != nil || in.TrackingID == "" {
+		return nil, a2a.NewError(a2a.ErrValidationFailed, "tracking_id is required")
+	}
+	c.mu.Lock()
+	sh, ok := c.shipments[in.TrackingID]
+	c.mu.Unlock()
+	if !ok {
+		return nil, a2a.NewError(a2a.ErrNotFound, "unknown tracking_id "+in.TrackingID)
+	}
+	return sh, nil
+}
+
+// passed: 지금까지 지난 체크포인트(잠금 필요)
+func (sh *shipment) passed(now time.Time) []a2a.Checkpoint {
+	n := 0
+	for n < len(sh.plan) && !sh.plan[n].At.After(now) {
+		n++
+	}
+	return sh.plan[:n]
+}
+
+// trackResult: TRACK 결과(TrackResult) — 지금까지의 체크포인트와 현재 상태
+type trackResult struct {
+	TrackingID        string             `json:"tracking_id"`
+	Carrier           string             `json:"carrier"`
+	Service           string             `json:"service"`
+	Status            a2a.ShipmentStatus `json:"status"`
+	Voided            bool               `json:"voided,omitempty"`
+	EstimatedDelivery time.Time          `json:"estimated_delivery"`
+	Checkpoints       []a2a.Checkpoint   `json:"checkpoints"`
+}
+
+func (c *carrier) snapshot(sh *shipment) trackResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cps := sh.passed(time.Now())
+	r := trackResult{
+		TrackingID: sh.trackingID, Carrier: c.card.Carrier, Service: sh.service,
+		Voided: sh.voided, EstimatedDelivery: sh.eta,
+		Checkpoints: append([]a2a.Checkpoint(nil), cps...),
+	}
+	if len(cps) > 0 {
+		r.Status = cps[len(cps)-1].Status
+	}
+	return r
+}
+
+// void: 집하 전이면 추적 중단, 이미 집하됐으면 CONFLICT. 모르는 tracking_id는 그대로 성공(보상 호출의 멱등성).
+func (c *carrier) void(trackingID string) *a2a.ErrorPayload {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sh, ok := c.shipments[trackingID]
+	if !ok || sh.voided {
+		return nil
+	}
+	passed := sh.passed(time.Now())
+	if len(passed) > 1 {
+		return a2a.NewError(a2a.ErrConflict, "shipment already "+string(passed[len(passed)-1].Status))
+	}
+	sh.plan, sh.voided = passed, true
+	close(sh.stop)
+	return nil
+}
+
+// follow: reply_url 구독 — 지난 체크포인트부터 하나씩 TRACKING_UPDATE로 보내고, 끝나면 TASK_COMPLETED(최종 스냅샷)
+func (c *carrier) follow(ct a2a.CreateTask, taskID string) {
+	sh, _ := c.shipment(ct.Input) // createTask의 run에서 이미 확인
+	var last a2a.ShipmentStatus   // 마지막으로 보낸 상태 — 구독자는 CanAdvance 순서로만 받는다
+	for i := 0; ; i++ {
+		c.mu.Lock()
+		var cp a2a.Checkpoint
+		next := i < len(sh.plan)
+		if next {
+			cp = sh.plan[i]
+		}
+		c.mu.Unlock()
+		if !next {
+			break
+		}
+		if wait := time.Until(cp.At); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-sh.stop:
+				i-- // 취소로 잘린 계획을 다시 확인
+				continue
+			}
+		}
+		if !a2a.CanAdvance(last, cp.Status) {
+			c.log.Printf("follow %s: skip illegal %s -> %s", sh.trackingID, last, cp.Status)
+			continue
+		}
+		last = cp.Status
+		c.notify(ct.ReplyURL, a2a.NewTrackingEvent(taskID, a2a.TrackingPayload{TrackingID: sh.trackingID, Carrier: c.card.Carrier, Checkpoint: cp}))
+		r := c.snapshot(sh)
+		c.update(taskID, func(t *a2a.Task) { t.Result, _ = json.Marshal(r) })
+	}
+	r := c.snapshot(sh)
+	t := c.update(taskID, func(t *a2a.Task) {
+		t.Status = a2a.StatusSucceeded
+		t.Result, _ = json.Marshal(r)
+	})
+	if t != nil {
+		c.notify(ct.ReplyURL, a2a.NewCompletedEvent(taskID, t.Result))
+	}
+}
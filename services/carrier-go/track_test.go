@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+	"a2a/label"
+)
+
+func testLabel(id string) label.Label {
+	return label.Label{TrackingID: id, Service: "STANDARD", From: label.Address{City: "Seoul", Country: "KR"}, To: label.Address{Country: "US"}}
+}
+
+func TestCheckPlan(t *testing.T) {
+	cp := func(ss ...a2a.ShipmentStatus) []a2a.Checkpoint {
+		var out []a2a.Checkpoint
+		for _, s := range ss {
+			out = append(out, a2a.Checkpoint{Status: s})
+		}
+		return out
+	}
+	tests := []struct {
+		name  string
+		plan  []a2a.Checkpoint
+		valid int
+		ok    bool
+	}{
+		{"full", cp(a2a.ShipmentLabelCreated, a2a.ShipmentPickedUp, a2a.ShipmentInTransit, a2a.ShipmentInTransit, a2a.ShipmentOutForDelivery, a2a.ShipmentDelivered), 6, true},
+		{"exception", cp(a2a.ShipmentLabelCreated, a2a.ShipmentPickedUp, a2a.ShipmentException), 3, true},
+		{"must start with label", cp(a2a.ShipmentPickedUp, a2a.ShipmentInTransit), 0, false},
+		{"skips pickup", cp(a2a.ShipmentLabelCreated, a2a.ShipmentInTransit), 1, false},
+		{"after delivered", cp(a2a.ShipmentLabelCreated, a2a.ShipmentPickedUp, a2a.ShipmentInTransit, a2a.ShipmentOutForDelivery, a2a.ShipmentDelivered, a2a.ShipmentInTransit), 5, false},
+		{"after exception", cp(a2a.ShipmentLabelCreated, a2a.ShipmentException, a2a.ShipmentDelivered), 2, false},
+	}
+	for _, tt := range tests {
+		valid, ok := checkPlan(tt.plan)
+		if len(valid) != tt.valid || ok != tt.ok {
+			t.Errorf("%s: valid = %d, ok = %v; want %d, %v", tt.name, len(valid), ok, tt.valid, tt.ok)
+		}
+	}
+}
+
+// 시드마다 사고 위치가 달라도 계획은 항상 상태 기계를 따른다
+func TestRegisterPlansFollowStateMachine(t *testing.T) {
+	for _, rate := range []float64{0, 1} {
+		c, _ := newTestCarrier(t, func(cfg *Config) { cfg.Tracking.ExceptionRate = rate })
+		for i := range 50 {
+			sh := c.register(testLabel(a2a.NewID("A-")), 1+i%5)
+			if _, ok := checkPlan(sh.plan); !ok {
+				t.Fatalf("exception_rate %v: illegal plan %+v", rate, sh.plan)
+			}
+			want := a2a.ShipmentDelivered
+			if rate == 1 {
+				want = a2a.ShipmentException
+			}
+			if last := sh.plan[len(sh.plan)-1].Status; last != want {
+				t.Errorf("exception_rate %v: plan ends with %s, want %s", rate, last, want)
+			}
+		}
+	}
+}
+
+func TestVoid(t *testing.T) {
+	c, _ := newTestCarrier(t, func(cfg *Config) { cfg.Tracking.Day = time.Hour })
+	sh := c.register(testLabel("A-1"), 3)
+	if err := c.void("A-1"); err != nil {
+		t.Fatalf("void before pickup: %v", err)
+	}
+	if r := c.snapshot(sh); !r.Voided || r.Status != a2a.ShipmentLabelCreated || len(sh.plan) != 1 {
+		t.Errorf("after void: %+v", r)
+	}
+	if err := c.void("A-1"); err != nil {
+		t.Errorf("second void: %v", err)
+	}
+	if err := c.void("A-unknown"); err != nil {
+		t.Errorf("unknown tracking_id: %v", err)
+	}
+
+	c, _ = newTestCarrier(t, func(cfg *Config) { cfg.Tracking.Day = time.Millisecond })
+	sh = c.register(testLabel("A-2"), 1)
+	time.Sleep(5 * time.Millisecond)
+	if r := c.snapshot(sh); r.Status != a2a.ShipmentDelivered {
+		t.Fatalf("status = %s, want DELIVERED", r.Status)
+	}
+	err := c.void("A-2")
+	if err == nil || err.Code != a2a.ErrConflict {
+		t.Fatalf("void after DELIVERED: %v, want CONFLICT", err)
+	}
+	if r := c.snapshot(sh); r.Voided || r.Status != a2a.ShipmentDelivered {
+		t.Errorf("refused void changed the shipment: %+v", r)
+	}
+}
+
+// TRACK + reply_url: 체크포인트를 상태 기계 순서대로 TRACKING_UPDATE로 보내고 TASK_COMPLETED로 끝낸다
+func TestFollowSendsOrderedUpdates(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		got  []a2a.Event
+		done = make(chan struct{})
+	)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev a2a.Event
+		json.NewDecoder(r.Body).Decode(&ev)
+		mu.Lock()
+		got = append(got, ev)
+		mu.Unlock()
+		if ev.Event == a2a.EventTaskCompleted {
+			close(done)
+		}
+		w.WriteHeader(204)
+	}))
+	defer sink.Close()
+
+	c, url := newTestCarrier(t, func(cfg *Config) { cfg.Tracking.Day = 20 * time.Millisecond })
+	c.register(testLabel("A-9"), 1)
+	status, ack := postTask(t, url, a2a.CreateTask{TaskType: "TRACK", Input: json.RawMessage(`{"tracking_id":"A-9"}`), ReplyURL: sink.URL})
+	if status != 202 || ack["status"] != string(a2a.StatusRunning) {
+		t.Fatalf("ack = %d %v", status, ack)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no TASK_COMPLETED")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var last a2a.ShipmentStatus
+	for i, ev := range got {
+		if ev.Seq != int64(i+1) || ev.TaskID != ack["task_id"] {
+			t.Errorf("event %d: seq %d task %s", i, ev.Seq, ev.TaskID)
+		}
+		if ev.Event != a2a.EventTrackingUpdate {
+			continue
+		}
+		var p a2a.TrackingPayload
+		json.Unmarshal(ev.Payload, &p)
+		if !a2a.CanAdvance(last, p.Checkpoint.Status) {
+			t.Errorf("update %d: %s -> %s", i, last, p.Checkpoint.Status)
+		}
+		last = p.Checkpoint.Status
+	}
+	if n := len(got); n != 7 || got[n-1].Event != a2a.EventTaskCompleted || last != a2a.ShipmentDelivered {
+		t.Errorf("events = %+v", got)
+	}
+	var res trackResult
+	if err := json.Unmarshal(got[len(got)-1].Payload, &res); err != nil || res.Status != a2a.ShipmentDelivered || len(res.Checkpoints) != 6 {
+		t.Errorf("final snapshot = %+v, %v", res, err)
+	}
+}
+
+// 알 수 없는 tracking_id는 NOT_FOUND, 입력이 없으면 VALIDATION_FAILED
+func TestShipmentLookup(t *testing.T) {
+	c, _ := newTestCarrier(t, nil)
+	for in, code := range map[string]string{`{"tracking_id":"A-404"}`: a2a.ErrNotFound, `{}`: a2a.ErrValidationFailed} {
+		if _, err := c.shipment(json.RawMessage(in)); err == nil || err.Code != code {
+			t.Errorf("%s: %v, want %s", in, err, code)
+		}
+	}
+}
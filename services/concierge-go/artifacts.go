@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+
+	a2a "a2a/contract"
+)
+
+// 하위 task의 첨부 파일(라벨 등)은 컨시어지 저장소로 가져와(Import) 상위 task에 붙인다.
+// 하위 에이전트의 서명 URL은 곧 만료되므로 클라이언트에는 컨시어지가 다시 서명한 URL만 보여준다.
+
+var artifacts = mustArtifactStore()
+
+func mustArtifactStore() *a2a.ArtifactStore {
+	s, err := a2a.NewArtifactStore(
+		env("ARTIFACT_DIR", filepath.Join(os.TempDir(), "concierge-artifacts")),
+		[]byte(os.Getenv("ARTIFACT_SECRET")), // 없으면 저장소에 보관한 키
+
+		env("PUBLIC_URL", selfURL),
+	)
+	if err != nil {
+		log.Fatal("artifact store: ", err)
+	}
+	return s
+}
+
+type artifactTaskKey struct{}
+
+// withArtifactTask: 이 ctx로 호출한 하위 task의 첨부 파일을 taskID에 모은다
+func withArtifactTask(ctx context.Context, taskID string) context.Context {
+	return context.WithValue(ctx, artifactTaskKey{}, taskID)
+}
+
+// collectArtifacts: 하위 task 첨부 파일을 가져와 상위 task에 추가(같은 sha256은 한 번만)
+func collectArtifacts(ctx context.Context, sub *a2a.Task) error {
+	taskID, _ := ctx.Value(artifactTaskKey{}).(string)
+	if taskID == "" || len(sub.Artifacts) == 0 {
+		return nil
+	}
+	got := make([]a2a.Artifact, 0, len(sub.Artifacts))
+	for _, a := range sub.Artifacts {
+		imported, err := artifacts.Import(ctx, client.HTTP, a)
+		if err != nil {
+			return err
+		}
+		got = append(got, imported)
+	}
+	_, err := tasks.Update(taskID, func(cur *a2a.Task) error {
+		for _, a := range got {
+			if !hasArtifact(cur.Artifacts, a.SHA256) {
+				cur.Artifacts = append(cur.Artifacts, a)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+func hasArtifact(as []a2a.Artifact, sum string) bool {
+	for _, a := range as {
+		if a.SHA256 == sum {
+			return true
+		}
+	}
+	return false
+}
+
+// signed: 응답용 사본 — 첨부 파일 URL을 새로 서명
+func signed(t *a2a.Task) *a2a.Task {
+	if len(t.Artifacts) == 0 {
+		return t
+	}
+	cp := *t
+	cp.Artifacts = artifacts.SignAll(t.Artifacts)
+	return &cp
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	a2a "a2a/contract"
+)
+
+// ====== 비동기 모드 ======
+
+// 하위 에이전트가 콜백을 보낼 때 쓰는 이 컨시어지의 외부 주소
+var selfURL = env("CONCIERGE_URL", "http://localhost:8080")
+
+// wantsAsync: meta.mode(있으면 우선), Prefer: respond-async 헤더, 또는 CONCIERGE_MODE=async.
+// 셋 다 없으면 동기(기본값) — 결과가 나올 때까지 기다려 응답한다.
+func wantsAsync(r *http.Request, ct *a2a.CreateTask) bool {
+	if mode, ok := ct.Meta["mode"].(string); ok {
+		return strings.EqualFold(mode, "async")
+	}
+	for _, p := range r.Header.Values("Prefer") {
+		if strings.Contains(strings.ToLower(p), "respond-async") {
+			return true
+		}
+	}
+	return strings.EqualFold(os.Getenv("CONCIERGE_MODE"), "async")
+}
+
+// 발신 콜백 서명 — A2A_SECRET이 없으면 서명하지 않음
+var signer = &a2a.Signer{AgentID: env("AGENT_ID", "agent.concierge-go"), Secret: []byte(os.Getenv("A2A_SECRET"))}
+
+// 발신 이벤트 기록(seq 부여, GET /tasks/{id}/events 재전송용)과 수신 이벤트 정렬/중복 제거
+var (
+	eventLog = a2a.NewEventLog()
+	inbox    = a2a.NewEventInbox()
+)
+
+// notify: 이벤트를 기록하고 호출자의 reply_url이 있으면 비동기 전송(실패는 로그만 —
+// 호출자는 GET /tasks/{id}/events?since=N 으로 따라잡을 수 있음)
+func notify(replyURL string, ev a2a.Event) {
+	ev = eventLog.Append(ev)
+	if replyURL == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := a2a.PostEvent(ctx, replyURL, ev, signer); err != nil {
+			log.Println("notify failed:", ev.TaskID, ev.Event, ev.Seq, err)
+		}
+	}()
+}
+
+// notifyDone: 최종 상태를 TASK_COMPLETED / TASK_FAILED(멈춤은 TASK_INPUT_REQUIRED)로 알림
+func notifyDone(replyURL string, t *a2a.Task) {
+	if t.Status == a2a.StatusInputRequired {
+		notify(replyURL, a2a.NewInputRequiredEvent(t.TaskID, t.InputRequired))
+		return
+	}
+	if t.Status == a2a.StatusFailed {
+		notify(replyURL, a2a.NewFailedEvent(t.TaskID, t.Error))
+		return
+	}
+	notify(replyURL, a2a.NewCompletedEvent(t.TaskID, t.Result))
+}
+
+// ====== 하위 작업 콜백 대기 ======
+//
+// 하위 에이전트가 PENDING으로 응답하면 reply_url(/tasks/sub_xxx/events)로 오는 콜백을 기다린다.
+// 콜백이 유실될 수 있으므로 /tasks/{id} 폴링을 함께 사용한다.
+// 구독 id는 추측할 수 없는 임의 값이고, 콜백은 expect로 묶은 하위 task_id의 이벤트만 받는다.
+
+type waiter struct {
+	ch     chan a2a.Event
+	remote string // 기다리는 하위 task_id(ack 전에는 비어 있음)
+}
+
+var waiters = struct {
+	mu sync.Mutex
+	m  map[string]*waiter
+}{m: map[string]*waiter{}}
+
+func subscribe() (id string, ch chan a2a.Event, cancel func()) {
+	id = a2a.NewID("sub_")
+	ch = make(chan a2a.Event, 8)
+	waiters.mu.Lock()
+	waiters.m[id] = &waiter{ch: ch}
+	waiters.mu.Unlock()
+	return id, ch, func() {
+		waiters.mu.Lock()
+		delete(waiters.m, id)
+		waiters.mu.Unlock()
+		inbox.Forget(id)
+	}
+}
+
+// expect: 구독 id로 받을 하위 task_id를 지정
+func expect(id, remoteTaskID string) {
+	waiters.mu.Lock()
+	defer waiters.mu.Unlock()
+	if w, ok := waiters.m[id]; ok {
+		w.remote = remoteTaskID
+	}
+}
+
+// awaited: 구독 id면 기다리는 하위 task_id와 true(아직 ack 전이면 "")
+func awaited(id string) (remote string, ok bool) {
+	waiters.mu.Lock()
+	defer waiters.mu.Unlock()
+	w, ok := waiters.m[id]
+	if !ok {
+		return "", false
+	}
+	return w.remote, true
+}
+
+// deliver: 대기 중인 하위 작업 콜백이면 전달하고 true
+func deliver(id string, ev a2a.Event) bool {
+	waiters.mu.Lock()
+	w, ok := waiters.m[id]
+	waiters.mu.Unlock()
+	if ok {
+		select {
+		case w.ch <- ev:
+		default: // 버퍼가 차면 진행 이벤트는 버려도 됨(폴링이 보완)
+		}
+	}
+	return ok
+}
+
+// awaitTask: 콜백 또는 폴링으로 하위 작업이 끝날 때까지 대기
+func awaitTask(ctx context.Context, baseURL, taskID string, events <-chan a2a.Event) (*a2a.Task, error) {
+	poll := time.NewTicker(500 * time.Millisecond)
+	defer poll.Stop()
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Event {
+			case a2a.EventTaskCompleted:
+				// 이벤트 내용은 믿지 않고 조회한 task의 상태로만 끝낸다(아직이면 폴링 계속)
+				if t, err := client.GetTask(ctx, baseURL, taskID, &a2a.NoRetry); err == nil && (a2a.IsTerminal(t.Status) || t.Status == a2a.StatusInputRequired) {
+					return t, nil
+				}
+			case a2a.EventTaskFailed:
+				var p a2a.FailedPayload
+				_ = json.Unmarshal(ev.Payload, &p)
+				return &a2a.Task{TaskID: taskID, Status: a2a.StatusFailed, Error: p.Error}, nil
+			case a2a.EventInputRequired:
+				var p a2a.InputRequest
+				_ = json.Unmarshal(ev.Payload, &p)
+				return &a2a.Task{TaskID: taskID, Status: a2a.StatusInputRequired, InputRequired: &p}, nil
+			}
+		case <-poll.C:
+			t, err := client.GetTask(ctx, baseURL, taskID, &a2a.NoRetry) // 다음 틱이 재시도
+			if err == nil && (a2a.IsTerminal(t.Status) || t.Status == a2a.StatusInputRequired) {
+				return t, nil
+			}
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
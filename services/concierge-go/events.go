@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	a2a "a2a/contract"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// postEvent: 하위 작업 콜백(sub_xxx)은 대기 중인 호출로 전달, 그 외는 해당 task에 반영
+func postEvent(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var ev a2a.Event
+	if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+		return
+	}
+	if err := a2a.ValidateEvent(&ev); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+		return
+	}
+	if remote, ok := awaited(id); ok {
+		// 하위 작업 콜백은 기다리는 하위 task의 이벤트만 받는다(ack 전 콜백은 폴링이 보완)
+		if remote == "" || ev.TaskID != remote {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, "task_id does not match the awaited task"))
+			return
+		}
+	} else if ev.TaskID != "" && ev.TaskID != id {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, "task_id does not match path"))
+		return
+	}
+	// 중복은 무시, seq 빈 구간이면 버퍼링 후 202. 적용에 실패한 이벤트는 버퍼에 남아 재전송 때 다시 시도된다.
+	applied, dup, err := inbox.Accept(id, ev, func(e a2a.Event) error {
+		if deliver(id, e) {
+			return nil
+		}
+		_, err := tasks.Update(id, func(t *a2a.Task) error { return applyEvent(t, e, requestAudit(r)) })
+		return err
+	})
+	if dup {
+		w.WriteHeader(204)
+		return
+	}
+	if applied == 0 && err == nil {
+		w.WriteHeader(202)
+		return
+	}
+	var ep *a2a.ErrorPayload
+	switch {
+	case errors.Is(err, a2a.ErrTaskNotFound):
+		w.WriteHeader(404)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
+	case errors.As(err, &ep):
+		w.WriteHeader(409)
+		json.NewEncoder(w).Encode(ep)
+	case err != nil:
+		w.WriteHeader(500)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrInternal, err.Error()))
+	default:
+		w.WriteHeader(204)
+	}
+}
+
+// applyEvent: 이벤트를 Task에 반영하고 이력에 남김. 허용되지 않는 상태 전이는 CONFLICT.
+func applyEvent(t *a2a.Task, ev a2a.Event, by a2a.Audit) error {
+	to := a2a.EventStatus(ev.Event)
+	// RUNNING 중 진행/추적 갱신은 상태 전이가 아님
+	update := ev.Event == a2a.EventTaskProgress || ev.Event == a2a.EventTrackingUpdate
+	if !(update && t.Status == a2a.StatusRunning) && !a2a.CanTransition(t.Status, to) {
+		return a2a.NewError(a2a.ErrConflict, "illegal transition "+string(t.Status)+" -> "+string(to))
+	}
+	switch ev.Event {
+	case a2a.EventTrackingUpdate:
+		var p a2a.TrackingPayload
+		if err := json.Unmarshal(ev.Payload, &p); err != nil {
+			return a2a.NewError(a2a.ErrValidationFailed, "tracking payload: "+err.Error())
+		}
+		var last a2a.ShipmentStatus
+		if n := len(t.Tracking); n > 0 {
+			last = t.Tracking[n-1].Status
+		}
+		if !a2a.CanAdvance(last, p.Checkpoint.Status) {
+			return a2a.NewError(a2a.ErrConflict, "illegal shipment transition "+string(last)+" -> "+string(p.Checkpoint.Status))
+		}
+		t.Tracking = append(append([]a2a.Checkpoint(nil), t.Tracking...), p.Checkpoint)
+	case a2a.EventTaskProgress:
+		var p a2a.ProgressPayload
+		_ = json.Unmarshal(ev.Payload, &p)
+		t.Progress = append(append([]a2a.ProgressEntry(nil), t.Progress...), a2a.ProgressEntry{ProgressPayload: p, At: time.Now().UTC()})
+	case a2a.EventTaskCompleted:
+		t.Result = ev.Payload
+	case a2a.EventTaskFailed:
+		var p a2a.FailedPayload
+		_ = json.Unmarshal(ev.Payload, &p)
+		t.Error = p.Error
+	case a2a.EventInputRequired:
+		var p a2a.InputRequest
+		_ = json.Unmarshal(ev.Payload, &p)
+		t.InputRequired = &p
+	}
+	t.Record(by, a2a.HistoryEntry{Action: a2a.HistoryEvent, EventID: ev.EventID, Reason: string(ev.Event)})
+	return t.SetStatus(to, by, string(ev.Event))
+}
+
+// requestAudit: 요청 헤더의 호출 주체/추적 ID(없으면 새로 발급)
+func requestAudit(r *http.Request) a2a.Audit {
+	tr := r.Header.Get(a2a.HeaderTraceID)
+	if tr == "" {
+		tr = a2a.NewID("tr_")
+	}
+	return a2a.Audit{Actor: r.Header.Get(a2a.HeaderAgentID), TraceID: tr}
+}
+
+// self: 이 컨시어지가 스스로 일으킨 변경
+func self(ctx context.Context) a2a.Audit {
+	return a2a.Audit{Actor: signer.AgentID, TraceID: a2a.TraceID(ctx)}
+}
+
+// eventAuth: A2A_SECRET이 설정되어 있으면 이벤트 발신자를 HMAC으로 인증
+func eventAuth() func(http.Handler) http.Handler {
+	secret := os.Getenv("A2A_SECRET")
+	if secret == "" {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return a2a.HMACMiddleware(func(string) ([]byte, bool) { return []byte(secret), true }, 2*time.Minute)
+}
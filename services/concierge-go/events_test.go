@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestApplyTrackingUpdateFollowsStateMachine(t *testing.T) {
+	task := &a2a.Task{TaskID: "t_1", Status: a2a.StatusRunning}
+	send := func(s a2a.ShipmentStatus) error {
+		return applyEvent(task, a2a.NewTrackingEvent(task.TaskID, a2a.TrackingPayload{TrackingID: "A-1", Checkpoint: a2a.Checkpoint{Status: s}}), a2a.Audit{})
+	}
+	var ep *a2a.ErrorPayload
+	if err := send(a2a.ShipmentInTransit); !errors.As(err, &ep) || ep.Code != a2a.ErrConflict {
+		t.Fatalf("first checkpoint IN_TRANSIT: err = %v, want CONFLICT", err)
+	}
+	for _, s := range []a2a.ShipmentStatus{a2a.ShipmentLabelCreated, a2a.ShipmentPickedUp, a2a.ShipmentInTransit, a2a.ShipmentInTransit} {
+		if err := send(s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+	}
+	if err := send(a2a.ShipmentDelivered); !errors.As(err, &ep) || ep.Code != a2a.ErrConflict {
+		t.Errorf("IN_TRANSIT -> DELIVERED: err = %v, want CONFLICT", err)
+	}
+	if err := send(a2a.ShipmentOutForDelivery); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(task.Tracking); n != 5 || task.Tracking[n-1].Status != a2a.ShipmentOutForDelivery {
+		t.Errorf("tracking = %+v", task.Tracking)
+	}
+	if task.Status != a2a.StatusRunning {
+		t.Errorf("status = %s", task.Status)
+	}
+}
+
+// 하위 작업 콜백은 expect로 묶은 하위 task_id의 이벤트만 받는다
+func TestSubscriptionAcceptsOnlyAwaitedTask(t *testing.T) {
+	r := chi.NewRouter()
+	r.Post("/tasks/{id}/events", postEvent)
+	id, ch, cancel := subscribe()
+	defer cancel()
+	post := func(ev a2a.Event) int {
+		b, _ := json.Marshal(ev)
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tasks/"+id+"/events", bytes.NewReader(b)))
+		return rec.Code
+	}
+	done := a2a.NewCompletedEvent("t_remote", json.RawMessage(`{}`))
+
+	if code := post(done); code != 400 {
+		t.Errorf("before ack: code = %d, want 400", code)
+	}
+	expect(id, "t_remote")
+	if code := post(a2a.NewCompletedEvent("t_other", json.RawMessage(`{}`))); code != 400 {
+		t.Errorf("other task: code = %d, want 400", code)
+	}
+	if code := post(done); code != 204 {
+		t.Fatalf("awaited task: code = %d, want 204", code)
+	}
+	select {
+	case ev := <-ch:
+		if ev.TaskID != "t_remote" {
+			t.Errorf("delivered %+v", ev)
+		}
+	default:
+		t.Error("event not delivered")
+	}
+	other, _, cancelOther := subscribe()
+	cancelOther()
+	if other == id || len(other) < len("sub_")+8 {
+		t.Errorf("subscription ids %q, %q look predictable", id, other)
+	}
+}
+
+// TASK_COMPLETED 이벤트만으로는 끝내지 않고 조회한 task의 상태를 따른다
+func TestAwaitTaskTrustsFetchedStatus(t *testing.T) {
+	var done atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		task := a2a.Task{TaskID: "t_remote", Status: a2a.StatusRunning}
+		if done.Load() {
+			task.Status, task.Result = a2a.StatusSucceeded, json.RawMessage(`{"from":"agent"}`)
+		}
+		json.NewEncoder(w).Encode(task)
+	}))
+	defer srv.Close()
+
+	events := make(chan a2a.Event, 1)
+	events <- a2a.NewCompletedEvent("t_remote", json.RawMessage(`{"from":"event"}`))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	time.AfterFunc(200*time.Millisecond, func() { done.Store(true) })
+	start := time.Now()
+	task, err := awaitTask(ctx, srv.URL, "t_remote", events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 200*time.Millisecond {
+		t.Error("returned before the agent reported completion")
+	}
+	if task.Status != a2a.StatusSucceeded || string(task.Result) != `{"from":"agent"}` {
+		t.Errorf("task = %s %s", task.Status, task.Result)
+	}
+}
@@ -2,4 +2,7 @@ module a2a/concierge
 
 go 1.24.2
 
-require github.com/go-chi/chi/v5 v5.2.3 // indirect
+require (
+	github.com/go-chi/chi/v5 v5.2.3 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	a2a "a2a/contract"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ====== INPUT_REQUIRED (되묻기) ======
+//
+// 하위 에이전트(예: interpreter)가 INPUT_REQUIRED를 돌려주면 워크플로는 그 단계에서 멈추고
+// 이 task도 INPUT_REQUIRED가 된다. POST /tasks/{id}/messages로 온 답은 하위 task로 전달되고,
+// 하위 task가 끝나면 끝난 단계는 건너뛰고 워크플로를 재개한다.
+
+// inputRequired: 하위 task가 추가 입력을 기다림
+type inputRequired struct {
+	BaseURL string
+	TaskID  string
+	Request *a2a.InputRequest
+}
+
+func (e *inputRequired) Error() string {
+	return "input required by " + e.BaseURL + "/tasks/" + e.TaskID
+}
+
+// postMessage: POST /tasks/{id}/messages
+func postMessage(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var m a2a.Message
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+		return
+	}
+	if err := a2a.ValidateMessage(&m); err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+		return
+	}
+	t, ok := tasks.Get(id)
+	if !ok {
+		w.WriteHeader(404)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
+		return
+	}
+	step := -1
+	for i, sr := range t.Steps {
+		if sr.Status == a2a.StatusInputRequired && sr.RemoteTaskID != "" {
+			step = i
+			break
+		}
+	}
+	wf := workflows[t.TaskType]
+	if t.Status != a2a.StatusInputRequired || step < 0 || wf == nil || t.Request == nil {
+		w.WriteHeader(409)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrConflict, "task is not waiting for input"))
+		return
+	}
+
+	caller := requestAudit(r)
+	w.Header().Set(a2a.HeaderTraceID, caller.TraceID)
+	ctx := a2a.WithTraceID(r.Context(), caller.TraceID)
+	sr := t.Steps[step]
+	if m.Text != "" {
+		sessions.Update(t.ContextID, func(s *a2a.Session) { s.Utterances = append(s.Utterances, m.Text) })
+	}
+	sub, err := client.SendMessage(ctx, sr.RemoteURL, sr.RemoteTaskID, m)
+	if err != nil {
+		w.WriteHeader(502)
+		json.NewEncoder(w).Encode(toErrorPayload(err))
+		return
+	}
+
+	t, err = tasks.Update(id, func(cur *a2a.Task) error {
+		if cur.Status != a2a.StatusInputRequired {
+			return a2a.NewError(a2a.ErrConflict, "task is not waiting for input")
+		}
+		cur.Record(caller, a2a.HistoryEntry{Action: a2a.HistoryMessage, StepID: sr.StepID, Reason: string(sub.Status)})
+		cur.Steps = append([]a2a.StepRun(nil), cur.Steps...)
+		run := &cur.Steps[step]
+		switch sub.Status {
+		case a2a.StatusInputRequired:
+			cur.InputRequired = sub.InputRequired
+		case a2a.StatusSucceeded:
+			now := time.Now().UTC()
+			run.Status, run.Result, run.FinishedAt = a2a.StatusSucceeded, sub.Result, &now
+			cur.Record(self(ctx), a2a.HistoryEntry{Action: a2a.HistoryStatus, StepID: sr.StepID, From: a2a.StatusInputRequired, To: a2a.StatusSucceeded, Reason: sr.Agent})
+			cur.InputRequired = nil
+		default:
+			now := time.Now().UTC()
+			run.Status, run.Error, run.FinishedAt = a2a.StatusFailed, sub.Error, &now
+			cur.Error, cur.InputRequired = sub.Error, nil
+			return cur.SetStatus(a2a.StatusFailed, self(ctx), "step "+sr.StepID+" failed after input")
+		}
+		return nil
+	})
+	if err != nil {
+		w.WriteHeader(409)
+		json.NewEncoder(w).Encode(toErrorPayload(err))
+		return
+	}
+	ct := *t.Request
+	switch t.Status {
+	case a2a.StatusInputRequired:
+		if sub.Status == a2a.StatusInputRequired {
+			json.NewEncoder(w).Encode(taskAck(t))
+			return
+		}
+		// 답이 완결됨 → 워크플로 재개
+		startTask(w, r, id, ct, func(ctx context.Context) *a2a.Task { return wf.Run(ctx, id, ct) }, caller)
+	default:
+		notifyDone(ct.ReplyURL, t)
+		json.NewEncoder(w).Encode(taskAck(t))
+	}
+}
+
+// taskAck: POST 응답 {task_id, status[, input_required]}
+func taskAck(t *a2a.Task) map[string]any {
+	ack := map[string]any{"task_id": t.TaskID, "status": t.Status, "context_id": t.ContextID}
+	if t.InputRequired != nil {
+		ack["input_required"] = t.InputRequired
+	}
+	return ack
+}
@@ -1,15 +1,17 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
-	"sync"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	a2a "a2a/contract"
@@ -17,26 +19,67 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-type store struct {
-	mu sync.Mutex
-	m  map[string]*a2a.Task
-}
-
-var st = store{m: map[string]*a2a.Task{}}
+// Task 저장소 — TASK_STORE_DIR가 있으면 파일로 영속화
+var tasks a2a.TaskStore = a2a.NewMemoryStore()
 
 var agentA = env("AGENT_A_URL", "http://localhost:8081")
 var agentB = env("AGENT_B_URL", "http://localhost:8082")
 var interpreter = env("INTERPRETER_URL", "http://localhost:8083")
 
+// 하위 에이전트 호출 — 기본 재시도 정책(DefaultRetryPolicy), 단계별 정책은 워크플로 YAML의 retry
+var client = a2a.NewClient(signer.AgentID, signer)
+
+// QUOTE fan-out 대상(쉼표 구분) — 기본은 Agent-A/B
+var carriers = splitList(env("CARRIER_URLS", agentA+","+agentB))
+
+// 워크플로 agent 이름 → base URL (워크플로 YAML의 agents로 추가/덮어쓰기 가능)
+var agentURLs = map[string]string{
+	"interpreter": interpreter,
+	"agent-a":     agentA,
+	"agent-b":     agentB,
+}
+
+// task_type → 워크플로 (workflows/*.yaml)
+var workflows map[string]*Workflow
+
 func main() {
+	if dir := os.Getenv("TASK_STORE_DIR"); dir != "" {
+		fs, err := a2a.NewFileStore(dir)
+		if err != nil {
+			log.Fatal("task store: ", err)
+		}
+		tasks = fs
+		ss, err := a2a.NewFileSessionStore(filepath.Join(dir, "sessions"))
+		if err != nil {
+			log.Fatal("session store: ", err)
+		}
+		sessions = ss
+	}
+	var err error
+	if workflows, err = loadWorkflows(); err != nil {
+		log.Fatal("workflows: ", err)
+	}
+
 	r := chi.NewRouter()
+	r.Use(a2a.DeadlineMiddleware)
 	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ok")) })
 
 	// Discovery(부팅 로그용 — 실패해도 동작엔 영향 없음)
-	go discover(agentA)
-	go discover(agentB)
+	for _, c := range carriers {
+		go discover(c)
+	}
+
+	r.Get("/.well-known/agent.json", func(w http.ResponseWriter, _ *http.Request) {
+		meta := a2a.AgentMeta{
+			AgentID: env("AGENT_ID", "agent.concierge-go"), Name: "Concierge (Go)", Version: "0.3.0",
+			ContractVer:  a2a.ContractVersion,
+			Capabilities: capabilities(),
+			Auth:         &a2a.AuthSpec{Required: false, Scheme: "HMAC"},
+		}
+		json.NewEncoder(w).Encode(meta)
+	})
 
-	// CreateTask(QUOTE/SHIP)
+	// CreateTask — 워크플로 trigger 또는 로컬 핸들러(RANK)
 	r.Post("/tasks", func(w http.ResponseWriter, r *http.Request) {
 		var ct a2a.CreateTask
 		if err := json.NewDecoder(r.Body).Decode(&ct); err != nil {
@@ -44,157 +87,263 @@ func main() {
 			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
 			return
 		}
-		taskID := "t_" + RandID()
-
-		switch ct.TaskType {
-		case "QUOTE":
-			// 1) 입력 검사
-			var raw map[string]any
-			_ = json.Unmarshal(ct.Input, &raw)
-			needsInterpret := false
-			if _, ok := raw["utterance"]; ok {
-				needsInterpret = true
-			} else {
-				// 필수 필드가 없으면 해석 필요
-				if _, ok := raw["from"]; !ok {
-					needsInterpret = true
-				}
-				if _, ok := raw["to"]; !ok {
-					needsInterpret = true
-				}
-				if _, ok := raw["parcel"]; !ok {
-					needsInterpret = true
-				}
-			}
+		if err := a2a.ValidateCreateTask(&ct); err != nil {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
+		}
+		wf, isWorkflow := workflows[ct.TaskType]
+		h, isLocal := localHandlers[ct.TaskType]
+		if !isWorkflow && !isLocal {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, "unsupported task_type"))
+			return
+		}
 
-			// 2) 해석 단계
-			quoteInput := ct.Input
-			if needsInterpret {
-				interpOut, err := postInterpret(interpreter, raw)
-				if err != nil {
-					w.WriteHeader(400)
-					json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, "interpret failed: "+err.Error()))
-					return
-				}
-				quoteInput = interpOut // 구조화된 QUOTE.input JSON
-			}
-			// fan-out to Agent-A/B
-			ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
-			defer cancel()
-			type qres struct {
-				ok   bool
-				data map[string]any
-				err  error
+		// 대화 context — 없으면 새로 시작(응답의 context_id로 이어서 요청)
+		if ct.ContextID == "" {
+			ct.ContextID = a2a.NewID("ctx_")
+		}
+
+		// 접수 기록 — 같은 idempotency_key가 이미 있으면 기존 task를 돌려준다
+		taskID := a2a.NewID("t_")
+		caller := requestAudit(r)
+		w.Header().Set(a2a.HeaderTraceID, caller.TraceID)
+		pending := &a2a.Task{
+			TaskID: taskID, Status: a2a.StatusPending,
+			TaskType: ct.TaskType, AgentID: caller.Actor, IdempotencyKey: ct.IdempotencyKey, ContextID: ct.ContextID,
+			CreatedAt: time.Now().UTC(), Request: &ct,
+		}
+		pending.Record(caller, a2a.HistoryEntry{At: pending.CreatedAt, Action: a2a.HistoryCreated, To: a2a.StatusPending})
+		if prev, dup := createTask(pending); dup {
+			json.NewEncoder(w).Encode(taskAck(prev))
+			return
+		}
+		beginSession(ct, taskID)
+
+		run := func(ctx context.Context) *a2a.Task {
+			if isWorkflow {
+				return wf.Run(ctx, taskID, ct)
 			}
-			ch := make(chan qres, 2)
-			go func() { q, err := postTask(agentA, "QUOTE", quoteInput); ch <- qres{ok: err == nil, data: q, err: err} }()
-			go func() { q, err := postTask(agentB, "QUOTE", quoteInput); ch <- qres{ok: err == nil, data: q, err: err} }()
-
-			var quotes []map[string]any
-			timeout := time.After(1800 * time.Millisecond)
-		loop:
-			for i := 0; i < 2; i++ {
-				select {
-				case r := <-ch:
-					if r.ok {
-						quotes = append(quotes, r.data)
-					}
-				case <-timeout:
-					break loop
-				case <-ctx.Done():
-					break loop
+			out, err := h(ctx, ct.Input)
+			t, uerr := tasks.Update(taskID, func(t *a2a.Task) error {
+				if err != nil {
+					t.Error = toErrorPayload(err)
+					t.Record(self(ctx), a2a.HistoryEntry{Action: a2a.HistoryResult, Reason: "error " + t.Error.Code})
+					return t.SetStatus(a2a.StatusFailed, self(ctx), ct.TaskType+" handler failed")
 				}
+				t.Result, _ = json.Marshal(out)
+				t.Record(self(ctx), a2a.HistoryEntry{Action: a2a.HistoryResult, Reason: ct.TaskType + " result"})
+				return t.SetStatus(a2a.StatusSucceeded, self(ctx), ct.TaskType+" handler done")
+			})
+			if uerr != nil {
+				log.Printf("[task] %s: store result: %v", taskID, uerr)
+				t, _ = tasks.Get(taskID)
 			}
-			// 결과 저장
-			res := map[string]any{"quotes": quotes, "partial_failures": []any{}}
-			b, _ := json.Marshal(res)
-			t := &a2a.Task{TaskID: taskID, Status: a2a.StatusSucceeded, Result: b}
-			st.mu.Lock()
-			st.m[taskID] = t
-			st.mu.Unlock()
-			json.NewEncoder(w).Encode(map[string]any{"task_id": taskID, "status": t.Status})
-
-		case "SHIP":
-			// 단순 위임(여기서는 Agent-A로 위임 예)
-			q, err := postTask(agentA, "SHIP", ct.Input)
-			status := a2a.StatusSucceeded
-			var result any = q
-			if err != nil {
-				status = a2a.StatusFailed
-				result = a2a.NewError(a2a.ErrInternal, err.Error())
-			}
-			b, _ := json.Marshal(result)
-			t := &a2a.Task{TaskID: taskID, Status: status, Result: b}
-			st.mu.Lock()
-			st.m[taskID] = t
-			st.mu.Unlock()
-			json.NewEncoder(w).Encode(map[string]any{"task_id": taskID, "status": status})
-		default:
+			return t
+		}
+
+		startTask(w, r, taskID, ct, run, caller)
+	})
+
+	// ListTasks — ?status=A,B&task_type=&agent_id=&idempotency_key=&context_id=&created_from=&created_to=&limit=&cursor=
+	r.Get("/tasks", func(w http.ResponseWriter, r *http.Request) {
+		f, err := parseTaskFilter(r.URL.Query())
+		if err != nil {
 			w.WriteHeader(400)
-			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, "unsupported task_type"))
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
+		}
+		p, err := tasks.List(f)
+		if errors.Is(err, a2a.ErrBadCursor) {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
+		}
+		if err != nil {
+			w.WriteHeader(500)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrInternal, err.Error()))
+			return
+		}
+		for i, t := range p.Tasks {
+			p.Tasks[i] = signed(t)
 		}
+		json.NewEncoder(w).Encode(p)
 	})
 
+	// ExportTasks — ListTasks와 같은 필터, 전체를 NDJSON으로(history 포함)
+	r.Get("/tasks/export", exportTasks)
+
 	// GetTask
 	r.Get("/tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		t, ok := tasks.Get(chi.URLParam(r, "id"))
+		if !ok {
+			w.WriteHeader(404)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
+			return
+		}
+		json.NewEncoder(w).Encode(signed(t))
+	})
+	r.Get("/artifacts/{sha}", artifacts.ServeHTTP)
+
+	// Context — 세션 상태와 이 context의 task 목록(최신순)
+	r.Get("/contexts/{id}", func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
-		st.mu.Lock()
-		t, ok := st.m[id]
-		st.mu.Unlock()
+		s, ok := sessions.Get(id)
+		if !ok {
+			w.WriteHeader(404)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "context not found"))
+			return
+		}
+		f, err := parseTaskFilter(r.URL.Query())
+		if err != nil {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
+		}
+		f.ContextID = id
+		p, err := tasks.List(f)
+		if err != nil {
+			w.WriteHeader(400)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
+		}
+		for i, t := range p.Tasks {
+			p.Tasks[i] = signed(t)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"session": s, "tasks": p.Tasks, "next_cursor": p.NextCursor})
+	})
+
+	// TaskHistory — 상태 전이/결과 기록/이벤트 반영 감사 이력
+	r.Get("/tasks/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		t, ok := tasks.Get(chi.URLParam(r, "id"))
 		if !ok {
 			w.WriteHeader(404)
 			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
 			return
 		}
-		json.NewEncoder(w).Encode(t)
+		json.NewEncoder(w).Encode(map[string]any{
+			"task_id": t.TaskID, "status": t.Status,
+			"created_at": t.CreatedAt, "updated_at": t.UpdatedAt, "completed_at": t.CompletedAt,
+			"history": t.History,
+		})
 	})
 
-	// Event 수신(비동기 완료시)
-	r.Post("/tasks/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+	// 추가 입력 — INPUT_REQUIRED task에 답하고 재개
+	r.Post("/tasks/{id}/messages", postMessage)
+
+	// Event 수신 — 하위 작업 콜백(sub_xxx)은 대기 중인 호출로 전달, 그 외는 해당 task에 반영
+	r.With(eventAuth()).Post("/tasks/{id}/events", postEvent)
+
+	// 놓친 콜백 따라잡기 — seq > since 인 발신 이벤트
+	r.Get("/tasks/{id}/events", func(w http.ResponseWriter, r *http.Request) {
 		id := chi.URLParam(r, "id")
-		body, _ := io.ReadAll(r.Body)
-		var ev a2a.Event
-		if err := json.Unmarshal(body, &ev); err == nil && ev.Event == "TASK_COMPLETED" {
-			// 완료 반영
-			st.mu.Lock()
-			if t, ok := st.m[id]; ok {
-				t.Status = a2a.StatusSucceeded
-				t.Result = ev.Payload
-			}
-			st.mu.Unlock()
+		if _, ok := tasks.Get(id); !ok {
+			w.WriteHeader(404)
+			json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
+			return
 		}
-		w.WriteHeader(204)
+		since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+		json.NewEncoder(w).Encode(map[string]any{"task_id": id, "events": eventLog.Since(id, since)})
 	})
 
 	log.Println("Concierge listening :8080")
 	http.ListenAndServe(":8080", r)
 }
 
-func postTask(baseURL, taskType string, input json.RawMessage) (map[string]any, error) {
-	req := a2a.CreateTask{TaskType: taskType, Input: input}
-	b, _ := json.Marshal(req)
-	resp, err := http.Post(baseURL+"/tasks", "application/json", bytes.NewReader(b))
+// startTask: run을 동기 또는 비동기로 실행하고 응답.
+// 비동기: 즉시 PENDING 응답, 완료는 reply_url 콜백 또는 GET /tasks/{id}로 확인
+func startTask(w http.ResponseWriter, r *http.Request, taskID string, ct a2a.CreateTask, run func(context.Context) *a2a.Task, caller a2a.Audit) {
+	if wantsAsync(r, &ct) {
+		// 응답 후에도 계속 실행 — 호출자의 마감과 trace는 유지
+		ctx, cancel := a2a.Detach(a2a.WithContextID(a2a.WithTraceID(r.Context(), caller.TraceID), ct.ContextID))
+		go func() {
+			defer cancel()
+			t := run(ctx)
+			remember(ct, t)
+			notifyDone(ct.ReplyURL, t)
+		}()
+		w.WriteHeader(202)
+		json.NewEncoder(w).Encode(map[string]any{"task_id": taskID, "status": a2a.StatusPending, "context_id": ct.ContextID})
+		return
+	}
+	t := run(a2a.WithContextID(a2a.WithTraceID(r.Context(), caller.TraceID), ct.ContextID))
+	remember(ct, t)
+	notifyDone(ct.ReplyURL, t)
+	json.NewEncoder(w).Encode(taskAck(t))
+}
+
+// capabilities: 워크플로 trigger + 로컬 핸들러
+func capabilities() []a2a.AgentCapability {
+	out := []a2a.AgentCapability{
+		{TaskType: "RANK", InputSchema: "RankRequest", OutputSchema: "RankResult"},
+		{TaskType: "SELECT_QUOTE", InputSchema: "SelectQuoteRequest", OutputSchema: "SelectQuoteResult"},
+	}
+	for tt, wf := range workflows {
+		if _, local := localHandlers[tt]; !local {
+			out = append(out, a2a.AgentCapability{TaskType: tt, InputSchema: wf.Schemas.Input, OutputSchema: wf.Schemas.Output})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TaskType < out[j].TaskType })
+	return out
+}
+
+func postTask(ctx context.Context, baseURL, taskType string, input json.RawMessage) (map[string]any, error) {
+	return postCreateTask(ctx, baseURL, a2a.CreateTask{TaskType: taskType, Input: input}, nil)
+}
+
+// postCreateTask: 하위 에이전트에 task 생성(retry 정책, nil이면 client 기본) 후 결과까지 대기
+func postCreateTask(ctx context.Context, baseURL string, req a2a.CreateTask, retry *a2a.RetryPolicy) (map[string]any, error) {
+	// 하위 에이전트가 비동기(PENDING)로 처리할 경우를 대비해 콜백 주소를 미리 등록.
+	// TRACK의 reply_url은 배송이 끝날 때까지의 구독 요청이라 붙이지 않는다(현재 상태만 동기로 받음).
+	subID, events, unsubscribe := subscribe()
+	defer unsubscribe()
+	if req.ReplyURL == "" && req.TaskType != "TRACK" {
+		req.ReplyURL = selfURL + "/tasks/" + subID + "/events"
+	}
+	ack, err := client.CreateTask(ctx, baseURL, req, retry)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	// 단순화를 위해: 에이전트가 동기 결과를 곧장 반환한다고 가정하고 /tasks/{id}를 생략
-	// (실제에선 id 받아서 /tasks/{id} 조회 or 콜백을 기다림)
+	expect(subID, ack.TaskID)
+	// 에이전트는 ack(task_id,status)만 돌려주므로 /tasks/{id}에서 결과를 가져온다
+	var t *a2a.Task
+	if ack.Status == a2a.StatusPending || ack.Status == a2a.StatusRunning {
+		t, err = awaitTask(ctx, baseURL, ack.TaskID, events)
+	} else {
+		t, err = client.GetTask(ctx, baseURL, ack.TaskID, retry)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t.Status == a2a.StatusInputRequired {
+		return nil, &inputRequired{BaseURL: baseURL, TaskID: t.TaskID, Request: t.InputRequired}
+	}
+	if t.Status == a2a.StatusFailed {
+		if t.Error != nil {
+			return nil, t.Error
+		}
+		return nil, fmt.Errorf("%s: task %s failed", baseURL, t.TaskID)
+	}
+	if err := collectArtifacts(ctx, t); err != nil {
+		return nil, a2a.NewError(a2a.ErrUnavailable, "artifact import: "+err.Error())
+	}
 	var rmap map[string]any
-	json.NewDecoder(resp.Body).Decode(&rmap)
-	// Agent가 즉시 SUCCEEDED로 저장했다면 /tasks/{id}에서 결과 가져와도 됨
-	// 여기선 간단 케이스로 agent의 동기 계산 결과를 한 번 더 GET 하지 않고 사용
-	switch taskType {
-	case "QUOTE":
-		// 데모용: Agent도 동기 작성이라면 /tasks/{id} 없이 바로 QuoteResult를 반환하도록 만들어도 됨
-		return rmap, nil
-	default:
-		return rmap, nil
+	if err := json.Unmarshal(t.Result, &rmap); err != nil {
+		return nil, err
 	}
+	return rmap, nil
 }
 
 func discover(base string) {
-	resp, err := http.Get(base + "/.well-known/agent.json")
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/.well-known/agent.json", nil)
+	if err != nil {
+		log.Println("discovery error:", base, err)
+		return
+	}
+	resp, err := client.HTTP.Do(req)
 	if err != nil {
 		log.Println("discovery error:", base, err)
 		return
@@ -211,47 +360,12 @@ func env(k, def string) string {
 	}
 	return def
 }
-func RandID() string { return "654321" } // TODO: uuid 교체
-
-func postInterpret(baseURL string, userInput map[string]any) (json.RawMessage, error) {
-	// userInput에 utterance가 없다면, 간단히 하나 만들어 LLM/규칙 파서로 넘겨도 됨
-	if _, ok := userInput["utterance"]; !ok {
-		// 문자열 합치기 (데모용)
-		b, _ := json.Marshal(userInput)
-		userInput = map[string]any{"utterance": string(b)}
-	}
-	// INTERPRET 태스크 전송
-	body := a2a.CreateTask{TaskType: "INTERPRET"}
-	bIn, _ := json.Marshal(map[string]any{"utterance": userInput["utterance"]})
-	body.Input = bIn
-	b, _ := json.Marshal(body)
-
-	resp, err := http.Post(baseURL+"/tasks", "application/json", bytes.NewReader(b))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var ack struct {
-		TaskID string `json:"task_id"`
-		Status string `json:"status"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&ack); err != nil {
-		return nil, err
-	}
-
-	// 곧바로 결과 조회
-	gr, err := http.Get(baseURL + "/tasks/" + ack.TaskID)
-	if err != nil {
-		return nil, err
-	}
-	defer gr.Body.Close()
-	var t a2a.Task
-	if err := json.NewDecoder(gr.Body).Decode(&t); err != nil {
-		return nil, err
-	}
-	if t.Status != a2a.StatusSucceeded {
-		return nil, fmt.Errorf("interpreter status=%s", t.Status)
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
 	}
-	return t.Result, nil
+	return out
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	a2a "a2a/contract"
+)
+
+// createTask 중복 확인과 저장을 한 번에(같은 키 동시 요청 대비)
+var createMu sync.Mutex
+
+// createTask: idempotency_key가 같은 task가 있으면 그것을 반환(dup=true), 없으면 t를 저장
+func createTask(t *a2a.Task) (*a2a.Task, bool) {
+	createMu.Lock()
+	defer createMu.Unlock()
+	if t.IdempotencyKey != "" {
+		p, err := tasks.List(a2a.TaskFilter{IdempotencyKey: t.IdempotencyKey, TaskType: t.TaskType, Limit: 1})
+		if err == nil && len(p.Tasks) > 0 {
+			return p.Tasks[0], true
+		}
+	}
+	tasks.Put(t)
+	return t, false
+}
+
+func parseTaskFilter(q url.Values) (a2a.TaskFilter, error) {
+	f := a2a.TaskFilter{
+		TaskType:       q.Get("task_type"),
+		AgentID:        q.Get("agent_id"),
+		IdempotencyKey: q.Get("idempotency_key"),
+		ContextID:      q.Get("context_id"),
+		Cursor:         q.Get("cursor"),
+	}
+	for _, s := range splitList(q.Get("status")) {
+		f.Status = append(f.Status, a2a.TaskStatus(strings.ToUpper(s)))
+	}
+	var err error
+	if v := q.Get("created_from"); v != "" {
+		if f.CreatedFrom, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, fmt.Errorf("created_from: %w", err)
+		}
+	}
+	if v := q.Get("created_to"); v != "" {
+		if f.CreatedTo, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, fmt.Errorf("created_to: %w", err)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 {
+			return f, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+	return f, nil
+}
+
+// exportTasks: GET /tasks/export — 필터에 맞는 task 전부를 NDJSON 한 줄씩(감사 이력 history와
+// created_at/updated_at/completed_at 포함). 페이지를 끝까지 따라가며 쓴다(limit은 페이지 크기).
+func exportTasks(w http.ResponseWriter, r *http.Request) {
+	f, err := parseTaskFilter(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(400)
+		json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+		return
+	}
+	if f.Limit == 0 {
+		f.Limit = a2a.MaxPageSize
+	}
+	enc := json.NewEncoder(w)
+	for n := 0; ; n++ {
+		p, err := tasks.List(f)
+		if err != nil {
+			if n == 0 { // 첫 페이지 전이면 아직 상태 코드를 바꿀 수 있다
+				w.WriteHeader(400)
+				enc.Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			}
+			return
+		}
+		if n == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		for _, t := range p.Tasks {
+			if err := enc.Encode(signed(t)); err != nil {
+				return // 클라이언트가 끊음
+			}
+		}
+		if p.NextCursor == "" || r.Context().Err() != nil {
+			return
+		}
+		f.Cursor = p.NextCursor
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+)
+
+// 내보내기는 페이지를 끝까지 따라가고 각 task의 감사 이력을 포함한다
+func TestExportTasksIncludesHistory(t *testing.T) {
+	old := tasks
+	tasks = a2a.NewMemoryStore()
+	t.Cleanup(func() { tasks = old })
+
+	by := a2a.Audit{Actor: "agent.test", TraceID: "tr_1"}
+	for i := range 3 {
+		task := &a2a.Task{TaskID: a2a.NewID("t_"), TaskType: "QUOTE", Status: a2a.StatusPending, CreatedAt: time.Now().UTC().Add(time.Duration(i) * time.Second)}
+		task.Record(by, a2a.HistoryEntry{Action: a2a.HistoryCreated, To: a2a.StatusPending})
+		task.SetStatus(a2a.StatusRunning, by, "started")
+		task.SetStatus(a2a.StatusSucceeded, by, "done")
+		tasks.Put(task)
+	}
+
+	rec := httptest.NewRecorder()
+	exportTasks(rec, httptest.NewRequest("GET", "/tasks/export?task_type=QUOTE&limit=1", nil))
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status %d, content-type %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	n := 0
+	for sc := bufio.NewScanner(rec.Body); sc.Scan(); n++ {
+		var task a2a.Task
+		if err := json.Unmarshal(sc.Bytes(), &task); err != nil {
+			t.Fatal(err)
+		}
+		if len(task.History) != 3 || task.History[2].To != a2a.StatusSucceeded || task.History[2].Actor != "agent.test" || task.CompletedAt.IsZero() {
+			t.Errorf("task %s: history = %+v", task.TaskID, task.History)
+		}
+	}
+	if n != 3 {
+		t.Errorf("exported %d tasks, want 3", n)
+	}
+
+	rec = httptest.NewRecorder()
+	exportTasks(rec, httptest.NewRequest("GET", "/tasks/export?limit=0", nil))
+	if rec.Code != 400 {
+		t.Errorf("bad filter: status %d", rec.Code)
+	}
+}
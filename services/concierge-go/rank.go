@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ====== RANK: 견적 정렬 ======
+
+const (
+	PolicyCheapest  = "CHEAPEST"
+	PolicyFastest   = "FASTEST"
+	PolicyBalanced  = "BALANCED"
+	PolicyPreferred = "PREFERRED" // 선호 캐리어 목록 우선 + balanced 점수
+)
+
+var rankPolicies = []string{PolicyCheapest, PolicyFastest, PolicyBalanced, PolicyPreferred}
+
+// RankPolicy: 가중치 기반 정렬 정책 (0 값은 정책별 기본값)
+type RankPolicy struct {
+	Policy            string   `json:"policy"`
+	PriceWeight       float64  `json:"price_weight,omitempty"`
+	SpeedWeight       float64  `json:"speed_weight,omitempty"`
+	PreferenceWeight  float64  `json:"preference_weight,omitempty"`
+	PreferredCarriers []string `json:"preferred_carriers,omitempty"`
+	Currency          string   `json:"currency,omitempty"` // 비교 기준 통화(기본 KRW)
+}
+
+// RankInput: RANK.input
+type RankInput struct {
+	Quotes   []map[string]any `json:"quotes"`
+	Policy   any              `json:"policy"`             // RankPolicy 객체 또는 정책 이름 — policyFrom으로 읽는다
+	Currency string           `json:"currency,omitempty"` // currency 없는 견적의 기본 통화
+}
+
+type RankedQuote struct {
+	Rank        int            `json:"rank"`
+	Score       float64        `json:"score"`
+	Carrier     string         `json:"carrier"`
+	Price       float64        `json:"price"` // 기준 통화로 환산된 가격
+	Currency    string         `json:"currency"`
+	EtaDays     float64        `json:"eta_days"`
+	Explanation string         `json:"explanation"`
+	Quote       map[string]any `json:"quote"` // 원본 견적
+}
+
+type RankResult struct {
+	Policy RankPolicy    `json:"policy"`
+	Ranked []RankedQuote `json:"ranked"`
+	// 가격/ETA를 읽을 수 없어 제외된 견적
+	Rejected []map[string]any `json:"rejected,omitempty"`
+}
+
+// 통화별 KRW 환산율 — FX_RATES="USD=1380,JPY=9.2" 로 덮어쓰기
+var fxToKRW = loadFX(env("FX_RATES", "KRW=1,USD=1380,JPY=9.2,EUR=1500,CNY=190"))
+
+func loadFX(v string) map[string]float64 {
+	m := map[string]float64{"KRW": 1}
+	for _, kv := range splitList(v) {
+		k, rate, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		if f, err := strconv.ParseFloat(strings.TrimSpace(rate), 64); err == nil && f > 0 {
+			m[strings.ToUpper(strings.TrimSpace(k))] = f
+		}
+	}
+	return m
+}
+
+func convert(amount float64, from, to string) (float64, error) {
+	rf, ok := fxToKRW[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %q", from)
+	}
+	rt, ok := fxToKRW[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %q", to)
+	}
+	return amount * rf / rt, nil
+}
+
+// policyFrom: RANK/SELECT_QUOTE 입력의 policy(보통 ${meta.rank_policy}) → RankPolicy.
+// 문자열이면 정책 이름("CHEAPEST"), 객체면 RankPolicy, 없으면 빈 값(기본 정책). 정책 이름 검사는 withDefaults.
+func policyFrom(v any) (RankPolicy, error) {
+	var p RankPolicy
+	switch x := v.(type) {
+	case nil:
+	case string:
+		p.Policy = x
+	default:
+		b, _ := json.Marshal(v)
+		if err := json.Unmarshal(b, &p); err != nil {
+			return RankPolicy{}, fmt.Errorf("malformed policy %s: want a policy name or object", b)
+		}
+	}
+	return p, nil
+}
+
+func (p RankPolicy) withDefaults() (RankPolicy, error) {
+	p.Policy = strings.ToUpper(p.Policy)
+	if p.Policy == "" {
+		p.Policy = PolicyBalanced
+	}
+	if p.Currency == "" {
+		p.Currency = "KRW"
+	}
+	p.Currency = strings.ToUpper(p.Currency)
+	if _, ok := fxToKRW[p.Currency]; !ok {
+		return p, fmt.Errorf("unknown currency %q", p.Currency)
+	}
+	explicit := p.PriceWeight != 0 || p.SpeedWeight != 0 || p.PreferenceWeight != 0
+	switch p.Policy {
+	case PolicyCheapest:
+		if !explicit {
+			p.PriceWeight, p.SpeedWeight = 1, 0.01 // ETA는 동점 처리용
+		}
+	case PolicyFastest:
+		if !explicit {
+			p.PriceWeight, p.SpeedWeight = 0.01, 1
+		}
+	case PolicyBalanced:
+		if !explicit {
+			p.PriceWeight, p.SpeedWeight = 0.6, 0.4
+		}
+	case PolicyPreferred:
+		if len(p.PreferredCarriers) == 0 {
+			return p, errors.New("preferred_carriers is required for PREFERRED")
+		}
+		if !explicit {
+			p.PriceWeight, p.SpeedWeight, p.PreferenceWeight = 0.3, 0.2, 0.5
+		}
+	default:
+		return p, fmt.Errorf("unsupported policy %q (allowed: %s)", p.Policy, strings.Join(rankPolicies, ", "))
+	}
+	if p.PriceWeight < 0 || p.SpeedWeight < 0 || p.PreferenceWeight < 0 {
+		return p, errors.New("weights must be >= 0")
+	}
+	return p, nil
+}
+
+// rankQuotes: 가격/ETA를 [0,1]로 정규화(낮을수록 1)한 뒤 가중합으로 정렬
+func rankQuotes(quotes []map[string]any, policy RankPolicy, defaultCurrency string) (RankResult, error) {
+	p, err := policy.withDefaults()
+	if err != nil {
+		return RankResult{}, err
+	}
+	if defaultCurrency == "" {
+		defaultCurrency = "KRW"
+	}
+	res := RankResult{Policy: p, Ranked: []RankedQuote{}}
+
+	for _, q := range quotes {
+		price, ok1 := number(q["price"])
+		eta, ok2 := number(q["eta_days"])
+		if !ok1 || !ok2 {
+			res.Rejected = append(res.Rejected, q)
+			continue
+		}
+		cur, _ := q["currency"].(string)
+		if cur == "" {
+			cur = defaultCurrency
+		}
+		norm, err := convert(price, strings.ToUpper(cur), p.Currency)
+		if err != nil {
+			res.Rejected = append(res.Rejected, q)
+			continue
+		}
+		carrier, _ := q["carrier"].(string)
+		res.Ranked = append(res.Ranked, RankedQuote{Carrier: carrier, Price: round2(norm), Currency: p.Currency, EtaDays: eta, Quote: q})
+	}
+	if len(res.Ranked) == 0 {
+		return res, nil
+	}
+
+	minP, maxP := res.Ranked[0].Price, res.Ranked[0].Price
+	minE, maxE := res.Ranked[0].EtaDays, res.Ranked[0].EtaDays
+	for _, r := range res.Ranked[1:] {
+		minP, maxP = math.Min(minP, r.Price), math.Max(maxP, r.Price)
+		minE, maxE = math.Min(minE, r.EtaDays), math.Max(maxE, r.EtaDays)
+	}
+	total := p.PriceWeight + p.SpeedWeight + p.PreferenceWeight
+
+	for i := range res.Ranked {
+		r := &res.Ranked[i]
+		ps := inverseNorm(r.Price, minP, maxP)
+		ss := inverseNorm(r.EtaDays, minE, maxE)
+		pref := preferenceScore(r.Carrier, p.PreferredCarriers)
+		r.Score = round4((p.PriceWeight*ps + p.SpeedWeight*ss + p.PreferenceWeight*pref) / total)
+		r.Explanation = fmt.Sprintf("%s: price %.0f %s (score %.2f x %.2f), eta %.0fd (score %.2f x %.2f)",
+			p.Policy, r.Price, r.Currency, ps, p.PriceWeight, r.EtaDays, ss, p.SpeedWeight)
+		if p.PreferenceWeight > 0 {
+			r.Explanation += fmt.Sprintf(", preference %.2f x %.2f", pref, p.PreferenceWeight)
+		}
+	}
+	sort.SliceStable(res.Ranked, func(i, j int) bool {
+		a, b := res.Ranked[i], res.Ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Price < b.Price
+	})
+	for i := range res.Ranked {
+		res.Ranked[i].Rank = i + 1
+	}
+	return res, nil
+}
+
+// 선호 목록 앞쪽일수록 1에 가깝고, 목록에 없으면 0
+func preferenceScore(carrier string, preferred []string) float64 {
+	for i, c := range preferred {
+		if strings.EqualFold(c, carrier) {
+			return 1 - float64(i)/float64(len(preferred))
+		}
+	}
+	return 0
+}
+
+func inverseNorm(v, lo, hi float64) float64 {
+	if hi == lo {
+		return 1
+	}
+	return (hi - v) / (hi - lo)
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func round2(f float64) float64 { return math.Round(f*100) / 100 }
+func round4(f float64) float64 { return math.Round(f*10000) / 10000 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	a2a "a2a/contract"
+)
+
+func TestPolicyFrom(t *testing.T) {
+	cases := []struct {
+		in      any
+		want    string
+		wantErr bool
+	}{
+		{nil, "", false},
+		{"CHEAPEST", "CHEAPEST", false},
+		{"fastest", "fastest", false}, // withDefaults가 대문자로
+		{map[string]any{"policy": "FASTEST", "currency": "USD"}, "FASTEST", false},
+		{"CHEAP", "CHEAP", false}, // 이름 검사는 withDefaults
+		{map[string]any{"policy": 3}, "", true},
+		{[]any{"CHEAPEST"}, "", true},
+		{42, "", true},
+	}
+	for _, c := range cases {
+		got, err := policyFrom(c.in)
+		if got.Policy != c.want || (err != nil) != c.wantErr {
+			t.Errorf("policyFrom(%v) = %+v, %v; want policy %q, error %v", c.in, got, err, c.want, c.wantErr)
+		}
+	}
+}
+
+// 직접 RANK: 정책은 엄격하게 — 틀리면 허용 정책을 알려 주는 VALIDATION_FAILED
+func TestRankPolicyStrict(t *testing.T) {
+	quotes := `[{"carrier":"A","price":10000,"currency":"KRW","eta_days":5},{"carrier":"B","price":20000,"currency":"KRW","eta_days":1}]`
+	cases := []struct {
+		policy string
+		top    string // 비면 오류
+		errMsg string
+	}{
+		{`"CHEAPEST"`, "A", ""},
+		{`"fastest"`, "B", ""},
+		{`null`, "A", ""}, // 기본 BALANCED
+		{`{"policy":"PREFERRED","preferred_carriers":["B"]}`, "B", ""},
+		{`"CHEAP"`, "", "allowed: CHEAPEST, FASTEST, BALANCED, PREFERRED"},
+		{`{"policy":"NOPE"}`, "", `unsupported policy "NOPE"`},
+		{`{"policy":"PREFERRED"}`, "", "preferred_carriers is required"},
+		{`{"policy":"CHEAPEST","currency":"XXX"}`, "", "unknown currency"},
+		{`42`, "", "malformed policy"},
+	}
+	for _, c := range cases {
+		for _, tt := range []string{"RANK", "SELECT_QUOTE"} {
+			out, err := localHandlers[tt](context.Background(), []byte(`{"quotes":`+quotes+`,"policy":`+c.policy+`}`))
+			if c.top == "" {
+				var ep *a2a.ErrorPayload
+				if !errors.As(err, &ep) || ep.Code != a2a.ErrValidationFailed || !strings.Contains(ep.Message, c.errMsg) {
+					t.Errorf("%s policy %s: err = %v, want VALIDATION_FAILED with %q", tt, c.policy, err, c.errMsg)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s policy %s: %v", tt, c.policy, err)
+				continue
+			}
+			var got string
+			switch r := out.(type) {
+			case RankResult:
+				got = r.Ranked[0].Carrier
+			case map[string]any:
+				got, _ = r["quote"].(map[string]any)["carrier"].(string)
+			}
+			if got != c.top {
+				t.Errorf("%s policy %s: top = %s, want %s", tt, c.policy, got, c.top)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	a2a "a2a/contract"
+)
+
+// ====== 대화 세션(context_id) ======
+//
+// 같은 context_id의 요청은 세션을 공유한다. 워크플로에서는 ${session.last_quotes} 처럼 참조하고,
+// SHIP은 견적이 없으면 SELECT_QUOTE로 세션의 마지막 견적 중에서 고른다("가장 싼 걸로 보내줘").
+
+// 세션 저장소 — TASK_STORE_DIR가 있으면 그 아래 sessions/에 영속화
+var sessions a2a.SessionStore = a2a.NewMemorySessionStore()
+
+// beginSession: 새 task를 세션에 등록(발화가 있으면 함께 기록)
+func beginSession(ct a2a.CreateTask, taskID string) {
+	sessions.Update(ct.ContextID, func(s *a2a.Session) {
+		s.TaskIDs = append(s.TaskIDs, taskID)
+		if u := utteranceOf(ct.Input); u != "" {
+			s.Utterances = append(s.Utterances, u)
+		}
+	})
+}
+
+// remember: 성공한 task의 결과를 세션에 반영 — 마지막 견적/확정된 요청/task_type별 결과
+func remember(ct a2a.CreateTask, t *a2a.Task) {
+	if t.Status != a2a.StatusSucceeded {
+		return
+	}
+	sessions.Update(ct.ContextID, func(s *a2a.Session) {
+		if s.State == nil {
+			s.State = map[string]json.RawMessage{}
+		}
+		s.State[ct.TaskType] = t.Result
+		var out struct {
+			Quotes []json.RawMessage `json:"quotes"`
+		}
+		if json.Unmarshal(t.Result, &out) == nil && len(out.Quotes) > 0 {
+			s.LastQuotes, _ = json.Marshal(out.Quotes)
+		}
+		// 확정된 요청: INTERPRET 단계 결과, 없으면 구조화 입력 그대로
+		for _, sr := range t.Steps {
+			if sr.TaskType == "INTERPRET" && sr.Status == a2a.StatusSucceeded {
+				s.LastRequest = sr.Result
+			}
+		}
+		if ct.TaskType == "QUOTE" && utteranceOf(ct.Input) == "" && !hasStep(t, "INTERPRET") {
+			s.LastRequest = ct.Input
+		}
+	})
+}
+
+func hasStep(t *a2a.Task, taskType string) bool {
+	for _, sr := range t.Steps {
+		if sr.TaskType == taskType && sr.Status == a2a.StatusSucceeded {
+			return true
+		}
+	}
+	return false
+}
+
+func utteranceOf(input json.RawMessage) string {
+	var in struct {
+		Utterance string `json:"utterance"`
+	}
+	_ = json.Unmarshal(input, &in)
+	return in.Utterance
+}
+
+// sessionScope: 워크플로 표현식의 session 루트
+func sessionScope(contextID string) any {
+	s, ok := sessions.Get(contextID)
+	if !ok {
+		return map[string]any{}
+	}
+	return toGeneric(s)
+}
+
+// ---- SELECT_QUOTE: 이전 견적에서 발화/정책으로 하나 고르기 ----------------------------
+
+// SelectInput: SELECT_QUOTE.input
+type SelectInput struct {
+	Quotes    []map[string]any `json:"quotes"`
+	Utterance string           `json:"utterance,omitempty"`
+	Policy    any              `json:"policy"`             // RankPolicy 또는 정책 이름(policyFrom) — 없으면 발화에서 추론
+	Shipment  json.RawMessage  `json:"shipment,omitempty"` // 발송 요청(보통 session.last_request)
+	Currency  string           `json:"currency,omitempty"`
+}
+
+func selectQuote(_ context.Context, input json.RawMessage) (any, error) {
+	var in SelectInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+	}
+	if len(in.Quotes) == 0 {
+		return nil, a2a.NewError(a2a.ErrValidationFailed, "no previous quotes in this context")
+	}
+	policy, err := policyFrom(in.Policy)
+	if err != nil {
+		return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+	}
+	if policy.Policy == "" {
+		policy = policyFromUtterance(in.Utterance, in.Quotes)
+	}
+	res, err := rankQuotes(in.Quotes, policy, in.Currency)
+	if err != nil {
+		return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+	}
+	if len(res.Ranked) == 0 {
+		return nil, a2a.NewError(a2a.ErrValidationFailed, "no rankable quotes in this context")
+	}
+	top := res.Ranked[0]
+	return map[string]any{
+		"quote":       top.Quote,
+		"shipment":    in.Shipment,
+		"policy":      res.Policy,
+		"explanation": top.Explanation,
+	}, nil
+}
+
+// policyFromUtterance: "가장 싼/cheapest" → CHEAPEST, "빠른/fastest" → FASTEST, 캐리어 이름 → PREFERRED
+func policyFromUtterance(u string, quotes []map[string]any) RankPolicy {
+	s := strings.ToLower(u)
+	for _, q := range quotes {
+		if c, _ := q["carrier"].(string); c != "" && strings.Contains(s, strings.ToLower(c)) {
+			return RankPolicy{Policy: PolicyPreferred, PreferredCarriers: []string{c}}
+		}
+	}
+	switch {
+	case containsAny(s, "cheapest", "cheap", "싼", "저렴", "최저"):
+		return RankPolicy{Policy: PolicyCheapest}
+	case containsAny(s, "fastest", "fast", "빠른", "빨리", "급"):
+		return RankPolicy{Policy: PolicyFastest}
+	}
+	return RankPolicy{Policy: PolicyBalanced}
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
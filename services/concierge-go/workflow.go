@@ -0,0 +1,618 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	a2a "a2a/contract"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ====== 선언형 워크플로(DAG) ======
+
+// 단계 전용 상태(Task에는 쓰지 않음)
+const (
+	StepSkipped  a2a.TaskStatus = "SKIPPED"
+	StepCanceled a2a.TaskStatus = "CANCELED"
+)
+
+// 예약된 agent 이름
+const (
+	AgentLocal    = "local"    // 컨시어지 내부 핸들러(RANK 등)
+	AgentCarriers = "carriers" // CARRIER_URLS 전체로 fan-out
+)
+
+type Workflow struct {
+	Name    string `yaml:"name"`
+	Trigger string `yaml:"trigger"` // 이 task_type의 CreateTask를 이 워크플로로 처리
+	Schemas struct {
+		Input  string `yaml:"input"`
+		Output string `yaml:"output"`
+	} `yaml:"schemas"` // discovery용 스키마 식별자
+	Timeout time.Duration     `yaml:"timeout"`
+	Agents  map[string]string `yaml:"agents"` // 이름 → base URL ($ENV 확장)
+	Steps   []Step            `yaml:"steps"`
+	Output  any               `yaml:"output"` // 최종 Task.result 매핑(없으면 마지막 단계 결과)
+	// task_type → 보상 작업. 워크플로 실패 시 성공했던 단계를 완료 역순으로 되돌린다.
+	Compensations map[string]Compensation `yaml:"compensations"`
+}
+
+// Compensation: 보상 작업 선언. input 매핑 루트는 input(원 단계 입력) | result(원 단계 결과)
+type Compensation struct {
+	TaskType    string        `yaml:"task_type"`
+	Input       any           `yaml:"input"`
+	MaxAttempts int           `yaml:"max_attempts"` // 기본 5, 소진 시 ESCALATED
+	Backoff     time.Duration `yaml:"backoff"`      // 첫 재시도 대기(지수 증가, 최대 5s)
+	Timeout     time.Duration `yaml:"timeout"`      // 시도별 마감(기본 3s)
+}
+
+type Step struct {
+	ID        string           `yaml:"id"`
+	TaskType  string           `yaml:"task_type"`
+	Agent     string           `yaml:"agent"` // local | carriers | agents 이름 | URL | ${...}
+	DependsOn []string         `yaml:"depends_on"`
+	When      string           `yaml:"when"`
+	Input     any              `yaml:"input"`
+	Timeout   time.Duration    `yaml:"timeout"`
+	FanOut    *FanOutSpec      `yaml:"fanout"` // agent=carriers 일 때만
+	Retry     *a2a.RetryPolicy `yaml:"retry"`  // 원격 호출 재시도(없으면 기본 정책)
+}
+
+type FanOutSpec struct {
+	PerTarget  time.Duration `yaml:"per_target"`
+	HedgeAfter time.Duration `yaml:"hedge_after"`
+	FirstK     int           `yaml:"first_k"`
+	Quorum     int           `yaml:"quorum"` // 미달 시 단계 실패
+}
+
+// LocalHandler: agent=local 단계 및 동일 task_type의 직접 요청 처리
+type LocalHandler func(ctx context.Context, input json.RawMessage) (any, error)
+
+var localHandlers = map[string]LocalHandler{
+	"RANK": func(_ context.Context, input json.RawMessage) (any, error) {
+		var in RankInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+		}
+		policy, err := policyFrom(in.Policy)
+		if err != nil {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+		}
+		out, err := rankQuotes(in.Quotes, policy, in.Currency)
+		if err != nil {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, err.Error())
+		}
+		return out, nil
+	},
+	"SELECT_QUOTE": selectQuote,
+}
+
+//go:embed workflows/*.yaml
+var builtinWorkflows embed.FS
+
+// loadWorkflows: WORKFLOW_DIR가 있으면 그 디렉터리, 없으면 내장 workflows/*.yaml
+func loadWorkflows() (map[string]*Workflow, error) {
+	var fsys fs.FS = builtinWorkflows
+	dir := "workflows"
+	if d := os.Getenv("WORKFLOW_DIR"); d != "" {
+		fsys, dir = os.DirFS(d), "."
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]*Workflow{}
+	for _, e := range entries {
+		if e.IsDir() || (!strings.HasSuffix(e.Name(), ".yaml") && !strings.HasSuffix(e.Name(), ".yml")) {
+			continue
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var wf Workflow
+		if err := yaml.Unmarshal(b, &wf); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if err := wf.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Name(), err)
+		}
+		if _, dup := out[wf.Trigger]; dup {
+			return nil, fmt.Errorf("%s: duplicate trigger %q", e.Name(), wf.Trigger)
+		}
+		out[wf.Trigger] = &wf
+	}
+	return out, nil
+}
+
+func (wf *Workflow) validate() error {
+	if wf.Trigger == "" {
+		return errors.New("trigger is required")
+	}
+	if len(wf.Steps) == 0 {
+		return errors.New("at least one step is required")
+	}
+	ids := map[string]bool{}
+	for _, s := range wf.Steps {
+		if s.ID == "" || s.TaskType == "" || s.Agent == "" {
+			return fmt.Errorf("step %q: id, task_type and agent are required", s.ID)
+		}
+		if ids[s.ID] {
+			return fmt.Errorf("duplicate step id %q", s.ID)
+		}
+		ids[s.ID] = true
+	}
+	for _, s := range wf.Steps {
+		for _, d := range s.DependsOn {
+			if !ids[d] {
+				return fmt.Errorf("step %q depends on unknown step %q", s.ID, d)
+			}
+		}
+	}
+	for tt, c := range wf.Compensations {
+		if c.TaskType == "" {
+			return fmt.Errorf("compensation for %q: task_type is required", tt)
+		}
+	}
+	// 순환 검사(Kahn)
+	indeg := map[string]int{}
+	for _, s := range wf.Steps {
+		indeg[s.ID] = len(s.DependsOn)
+	}
+	for done := 0; done < len(wf.Steps); done++ {
+		next := ""
+		for _, s := range wf.Steps {
+			if indeg[s.ID] == 0 {
+				next = s.ID
+				break
+			}
+		}
+		if next == "" {
+			return errors.New("steps contain a dependency cycle")
+		}
+		indeg[next] = -1
+		for _, s := range wf.Steps {
+			for _, d := range s.DependsOn {
+				if d == next {
+					indeg[s.ID]--
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Run: 단계들을 의존성 순서대로(독립 단계는 병렬) 실행하며 매 단계마다 저장소에 반영
+func (wf *Workflow) Run(ctx context.Context, taskID string, ct a2a.CreateTask) *a2a.Task {
+	if wf.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, wf.Timeout)
+		defer cancel()
+	}
+	ctx, stop := context.WithCancel(withArtifactTask(ctx, taskID))
+	defer stop()
+
+	t := &a2a.Task{TaskID: taskID, Status: a2a.StatusRunning, Steps: make([]a2a.StepRun, len(wf.Steps))}
+	prev, _ := tasks.Get(taskID)
+	index := map[string]int{}
+	for i, s := range wf.Steps {
+		index[s.ID] = i
+		t.Steps[i] = a2a.StepRun{StepID: s.ID, TaskType: s.TaskType, Status: a2a.StatusPending}
+		// 재개(INPUT_REQUIRED 답변 후): 이전 실행에서 끝난 단계는 다시 실행하지 않음
+		if prev != nil && i < len(prev.Steps) && prev.Steps[i].StepID == s.ID &&
+			(prev.Steps[i].Status == a2a.StatusSucceeded || prev.Steps[i].Status == StepSkipped) {
+			t.Steps[i] = prev.Steps[i]
+		}
+	}
+	_, err := tasks.Update(taskID, func(cur *a2a.Task) error {
+		cur.Steps = append([]a2a.StepRun(nil), t.Steps...) // Run이 t.Steps를 계속 고치므로 복사본 저장
+		reason := "workflow " + wf.Name + " started"
+		if cur.Status == a2a.StatusInputRequired {
+			reason = "workflow " + wf.Name + " resumed"
+		}
+		return cur.SetStatus(a2a.StatusRunning, self(ctx), reason)
+	})
+	if err != nil {
+		// 없는 task이거나 이미 끝난 task — 단계를 실행하지 않는다
+		log.Printf("[workflow] %s/%s: cannot start: %v", wf.Name, taskID, err)
+		t.Status, t.Error = a2a.StatusFailed, toErrorPayload(err)
+		return t
+	}
+
+	steps := map[string]any{}
+	scope := map[string]any{"input": toGeneric(ct.Input), "meta": toGeneric(ct.Meta), "steps": steps, "session": sessionScope(ct.ContextID)}
+	var completed []int // 성공 순서
+	for i, sr := range t.Steps {
+		switch sr.Status {
+		case a2a.StatusSucceeded:
+			steps[sr.StepID] = map[string]any{"status": string(sr.Status), "result": toGeneric(sr.Result)}
+			completed = append(completed, i)
+		case StepSkipped:
+			steps[sr.StepID] = map[string]any{"status": string(sr.Status)}
+		}
+	}
+	save := func(i int) {
+		run := t.Steps[i]
+		_, err := tasks.Update(taskID, func(cur *a2a.Task) error {
+			cur.Steps = append([]a2a.StepRun(nil), cur.Steps...)
+			if from := cur.Steps[i].Status; from != run.Status {
+				cur.Record(self(ctx), a2a.HistoryEntry{Action: a2a.HistoryStatus, StepID: run.StepID, From: from, To: run.Status, Reason: run.Agent})
+			}
+			cur.Steps[i] = run
+			return nil
+		})
+		if err != nil {
+			log.Printf("[workflow] %s/%s: save step %s: %v", wf.Name, taskID, run.StepID, err)
+		}
+	}
+
+	type done struct {
+		i      int
+		result any
+		err    *a2a.ErrorPayload
+		ask    *inputRequired
+	}
+	ch := make(chan done, len(wf.Steps))
+	running := 0
+	var failure *a2a.ErrorPayload
+	var paused *inputRequired // 추가 입력을 기다리는 단계(있으면 INPUT_REQUIRED로 멈춤)
+
+	for {
+		// 실행 가능한 단계 찾기(상태가 바뀌면 다시 훑음 — YAML 순서가 위상 순서가 아니어도 됨)
+		for changed := true; changed; {
+			changed = false
+			for i, s := range wf.Steps {
+				if t.Steps[i].Status != a2a.StatusPending {
+					continue
+				}
+				ready, blocked := true, failure != nil
+				for _, d := range s.DependsOn {
+					switch t.Steps[index[d]].Status {
+					case a2a.StatusSucceeded, StepSkipped:
+					case a2a.StatusFailed, StepCanceled:
+						blocked = true
+					default:
+						ready = false
+					}
+				}
+				now := time.Now().UTC()
+				switch {
+				case blocked:
+					t.Steps[i].Status, t.Steps[i].FinishedAt = StepCanceled, &now
+					steps[s.ID] = map[string]any{"status": string(StepCanceled)}
+					save(i)
+					changed = true
+					continue
+				case !ready:
+					continue
+				case !evalCond(s.When, scope):
+					t.Steps[i].Status, t.Steps[i].FinishedAt = StepSkipped, &now
+					steps[s.ID] = map[string]any{"status": string(StepSkipped)}
+					save(i)
+					changed = true
+					continue
+				}
+				agent, err1 := resolveString(s.Agent, scope)
+				input, err2 := resolve(s.Input, scope)
+				if err := errors.Join(err1, err2); err != nil {
+					t.Steps[i].Status, t.Steps[i].FinishedAt = a2a.StatusFailed, &now
+					t.Steps[i].Error = a2a.NewError(a2a.ErrValidationFailed, err.Error())
+					failure = t.Steps[i].Error
+					steps[s.ID] = map[string]any{"status": string(a2a.StatusFailed)}
+					save(i)
+					changed = true
+					continue
+				}
+				t.Steps[i].Input, _ = json.Marshal(input) // 재개한 실행에서도 보상 매핑에 쓰도록 함께 저장
+				t.Steps[i].Status, t.Steps[i].StartedAt, t.Steps[i].Agent = a2a.StatusRunning, &now, stringify(agent)
+				save(i)
+				changed = true
+				running++
+				go func(i int, s Step, agent string, input any) {
+					res, err := wf.execStep(ctx, s, agent, input)
+					var ask *inputRequired
+					switch {
+					case errors.As(err, &ask):
+						ch <- done{i: i, ask: ask}
+					case err != nil:
+						ch <- done{i: i, err: toErrorPayload(err)}
+					default:
+						ch <- done{i: i, result: res}
+					}
+				}(i, s, stringify(agent), input)
+			}
+		}
+		if running == 0 {
+			break
+		}
+
+		d := <-ch
+		running--
+		now := time.Now().UTC()
+		run := &t.Steps[d.i]
+		run.FinishedAt = &now
+		if d.ask != nil {
+			// 끝난 것이 아님 — 답을 받으면 RemoteTaskID로 전달하고 재개
+			run.Status, run.FinishedAt = a2a.StatusInputRequired, nil
+			run.RemoteURL, run.RemoteTaskID = d.ask.BaseURL, d.ask.TaskID
+			if paused == nil {
+				paused = d.ask
+			}
+		} else if d.err != nil {
+			run.Status, run.Error = a2a.StatusFailed, d.err
+			if failure == nil {
+				failure = d.err
+				stop() // 실행 중인 다른 단계도 중단
+			}
+		} else {
+			run.Status = a2a.StatusSucceeded
+			run.Result, _ = json.Marshal(d.result)
+			completed = append(completed, d.i)
+		}
+		steps[run.StepID] = map[string]any{"status": string(run.Status), "result": toGeneric(run.Result), "error": toGeneric(run.Error)}
+		save(d.i)
+
+		finished := 0
+		for _, sr := range t.Steps {
+			if sr.FinishedAt != nil {
+				finished++
+			}
+		}
+		notify(ct.ReplyURL, a2a.NewProgressEvent(taskID, finished*100/len(t.Steps), run.StepID+" "+string(run.Status)))
+	}
+
+	if failure != nil {
+		t.Status, t.Error = a2a.StatusFailed, failure
+		// 보상은 호출자의 취소/마감과 무관하게 끝까지(trace 등 값은 유지)
+		wf.compensate(context.WithoutCancel(ctx), taskID, t, completed, func(e a2a.SagaEntry) {
+			t.Saga = append(t.Saga, e)
+			_, err := tasks.Update(taskID, func(cur *a2a.Task) error {
+				cur.Saga = append(cur.Saga, e)
+				return nil
+			})
+			if err != nil {
+				log.Printf("[saga] %s/%s: save %s: %v", taskID, e.StepID, e.Status, err)
+			}
+		})
+	} else if paused != nil {
+		t.Status, t.InputRequired = a2a.StatusInputRequired, paused.Request
+	} else {
+		out, err := wf.output(scope, t)
+		if err != nil {
+			t.Status, t.Error = a2a.StatusFailed, a2a.NewError(a2a.ErrInternal, "output: "+err.Error())
+		} else {
+			t.Status, t.Result = a2a.StatusSucceeded, out
+		}
+	}
+	final, err := tasks.Update(taskID, func(cur *a2a.Task) error {
+		cur.Result, cur.Error, cur.Steps, cur.Saga, cur.InputRequired = t.Result, t.Error, t.Steps, t.Saga, t.InputRequired
+		reason := "workflow " + wf.Name + " completed"
+		switch {
+		case t.Error != nil:
+			reason = "workflow " + wf.Name + " failed: " + t.Error.Code
+		case t.InputRequired != nil:
+			reason = "workflow " + wf.Name + " waiting for input"
+		}
+		if t.InputRequired == nil {
+			cur.Record(self(ctx), a2a.HistoryEntry{Action: a2a.HistoryResult, Reason: reason})
+		}
+		return cur.SetStatus(t.Status, self(ctx), reason)
+	})
+	if err != nil {
+		log.Printf("[workflow] %s/%s: save final status %s: %v", wf.Name, taskID, t.Status, err)
+		return t
+	}
+	return final
+}
+
+func (wf *Workflow) output(scope map[string]any, t *a2a.Task) (json.RawMessage, error) {
+	if wf.Output == nil {
+		for i := len(t.Steps) - 1; i >= 0; i-- {
+			if t.Steps[i].Status == a2a.StatusSucceeded {
+				return t.Steps[i].Result, nil
+			}
+		}
+		return nil, nil
+	}
+	v, err := resolve(wf.Output, scope)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (wf *Workflow) execStep(ctx context.Context, s Step, agent string, input any) (any, error) {
+	raw, _ := json.Marshal(input)
+	timeout := s.Timeout
+	// fan-out 단계는 입력의 max_wait_ms가 있으면 그것이 단계 마감 — 단계 timeout보다 길어지지는 않는다
+	if wait := maxWait(raw); s.FanOut != nil && wait > 0 {
+		if timeout > 0 {
+			timeout = min(timeout, wait)
+		} else {
+			timeout = wait
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return wf.dispatch(ctx, agent, a2a.CreateTask{TaskType: s.TaskType, Input: raw}, s.FanOut, s.Retry)
+}
+
+// dispatch: agent 이름에 따라 로컬 핸들러 / 캐리어 fan-out / 원격 에이전트로 보낸다
+// 하위 에이전트가 추가 입력을 요구하면 *inputRequired, 그 외 오류는 *a2a.ErrorPayload
+func (wf *Workflow) dispatch(ctx context.Context, agent string, ct a2a.CreateTask, spec *FanOutSpec, retry *a2a.RetryPolicy) (any, error) {
+	var (
+		res any
+		err error
+	)
+	switch agent {
+	case AgentLocal:
+		h, ok := localHandlers[ct.TaskType]
+		if !ok {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, "no local handler for "+ct.TaskType)
+		}
+		res, err = h(ctx, ct.Input)
+	case AgentCarriers:
+		res, err = fanOut(ctx, ct, spec, retry)
+	default:
+		base, ok := wf.agentURL(agent)
+		if !ok {
+			return nil, a2a.NewError(a2a.ErrValidationFailed, fmt.Sprintf("unknown agent %q", agent))
+		}
+		res, err = postCreateTask(ctx, base, ct, retry)
+	}
+	var ask *inputRequired
+	if errors.As(err, &ask) {
+		return nil, ask
+	}
+	if err != nil {
+		return nil, toErrorPayload(err)
+	}
+	return res, nil
+}
+
+// agentURL: 에이전트 이름(workflow agents, 기본 에이전트) 또는 설정된 캐리어 URL만 허용.
+// 호출자가 넣은 임의 URL로는 요청을 보내지 않는다.
+func (wf *Workflow) agentURL(agent string) (string, bool) {
+	if u, ok := wf.Agents[agent]; ok {
+		return os.ExpandEnv(u), true
+	}
+	if u, ok := agentURLs[agent]; ok {
+		return u, true
+	}
+	for _, c := range carriers {
+		if strings.TrimRight(c, "/") == strings.TrimRight(agent, "/") {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// compensate: 성공한 단계를 완료 역순으로 보상. 재시도는 같은 idempotency_key로 보내므로
+// 에이전트가 이미 처리했다면 중복 실행되지 않는다. ctx가 끝나면 재시도를 멈추고 ESCALATED.
+func (wf *Workflow) compensate(ctx context.Context, taskID string, t *a2a.Task, completed []int, record func(a2a.SagaEntry)) {
+	for k := len(completed) - 1; k >= 0; k-- {
+		run := t.Steps[completed[k]]
+		c, ok := wf.Compensations[run.TaskType]
+		if !ok {
+			continue
+		}
+		entry := a2a.SagaEntry{StepID: run.StepID, TaskType: c.TaskType, Agent: run.Agent}
+		scope := map[string]any{"input": toGeneric(run.Input), "result": toGeneric(run.Result)}
+		input, err := resolve(c.Input, scope)
+		if err != nil {
+			entry.Status, entry.Error, entry.At = a2a.SagaEscalated, a2a.NewError(a2a.ErrValidationFailed, err.Error()), time.Now().UTC()
+			record(entry)
+			log.Printf("[saga] %s/%s: escalated: %v", taskID, run.StepID, err)
+			continue
+		}
+		raw, _ := json.Marshal(input)
+		ct := a2a.CreateTask{TaskType: c.TaskType, Input: raw, IdempotencyKey: taskID + ":" + run.StepID + ":" + c.TaskType}
+
+		maxAttempts, backoff, timeout := c.MaxAttempts, c.Backoff, c.Timeout
+		if maxAttempts <= 0 {
+			maxAttempts = 5
+		}
+		if backoff <= 0 {
+			backoff = 200 * time.Millisecond
+		}
+		if timeout <= 0 {
+			timeout = 3 * time.Second
+		}
+		for attempt := 1; ; attempt++ {
+			actx, cancel := context.WithTimeout(ctx, timeout)
+			res, err := wf.dispatch(actx, run.Agent, ct, nil, &a2a.NoRetry) // 재시도는 이 루프가 담당
+			cancel()
+			var ep *a2a.ErrorPayload
+			if err != nil {
+				ep = toErrorPayload(err)
+			}
+
+			entry.Attempt, entry.At, entry.Error, entry.Result = attempt, time.Now().UTC(), ep, nil
+			if ep == nil {
+				entry.Status = a2a.SagaCompensated
+				entry.Result, _ = json.Marshal(res)
+				record(entry)
+				break
+			}
+			if attempt >= maxAttempts || ctx.Err() != nil {
+				entry.Status = a2a.SagaEscalated
+				record(entry)
+				log.Printf("[saga] %s/%s: %s escalated after %d attempts: %v", taskID, run.StepID, c.TaskType, attempt, ep)
+				break
+			}
+			entry.Status = a2a.SagaRetrying
+			record(entry)
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+			}
+			backoff = min(backoff*2, 5*time.Second)
+		}
+	}
+}
+
+// fanOut: 캐리어 전체에 같은 task를 보내고 {quotes, partial_failures}로 모은다
+func fanOut(ctx context.Context, ct a2a.CreateTask, s *FanOutSpec, retry *a2a.RetryPolicy) (any, error) {
+	spec := FanOutSpec{}
+	if s != nil {
+		spec = *s
+	}
+	fo := a2a.ScatterGather(ctx, carriers, func(ctx context.Context, base string) (map[string]any, error) {
+		q, err := postCreateTask(ctx, base, ct, retry)
+		if err == nil {
+			q["agent_url"] = base // 후속 단계(SHIP 등)가 같은 캐리어로 보낼 수 있게
+		}
+		return q, err
+	}, a2a.FanOutOptions{
+		Deadline:   maxWait(ct.Input),
+		PerTarget:  spec.PerTarget,
+		HedgeAfter: spec.HedgeAfter,
+		FirstK:     spec.FirstK,
+		Quorum:     spec.Quorum,
+	})
+	if !fo.QuorumMet {
+		return nil, a2a.NewError(a2a.ErrTimeout, fmt.Sprintf("quorum not met: %d/%d succeeded", fo.Succeeded, spec.Quorum))
+	}
+	quotes := append([]map[string]any{}, fo.Successes()...)
+	partialFailures := append([]a2a.Outcome[map[string]any]{}, fo.Failures()...) // 빈 배열로 직렬화
+	return map[string]any{"quotes": quotes, "partial_failures": partialFailures}, nil
+}
+
+// maxWait: 입력의 max_wait_ms(QuoteRequest) — 없거나 0이면 0
+func maxWait(input json.RawMessage) time.Duration {
+	var in struct {
+		MaxWait int64 `json:"max_wait_ms"`
+	}
+	if json.Unmarshal(input, &in) != nil || in.MaxWait <= 0 {
+		return 0
+	}
+	return time.Duration(in.MaxWait) * time.Millisecond
+}
+
+func toErrorPayload(err error) *a2a.ErrorPayload {
+	var ep *a2a.ErrorPayload
+	var ask *inputRequired
+	switch {
+	case errors.As(err, &ep):
+		return ep
+	case errors.As(err, &ask):
+		return a2a.NewError(a2a.ErrValidationFailed, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return a2a.NewError(a2a.ErrTimeout, err.Error())
+	default:
+		return a2a.NewError(a2a.ErrInternal, err.Error())
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ====== 워크플로 표현식 ======
+//
+// 입력 매핑:  "${steps.quote.result.quotes}"        → 참조 값 그대로(타입 유지)
+//            "${input.utterance ?? input | string}" → 앞이 비면 뒤 값, string 필터로 문자열화
+//            "tracking: ${steps.ship.result.tracking_id}" → 문자열 보간
+// 경로 루트:  input | meta | session | steps.<id>.(status|result|error), 보상 매핑에서는 input | result
+// 경로 요소:  맵 키, 배열 인덱스(0,1..), # = 길이
+// 조건(when): "a || !b && c.# > 0" — 괄호 없음, && 가 || 보다 먼저 묶임
+// 연산자(??, |, ||, &&, 비교)와 식의 끝 }는 따옴표 문자열과 {...}/[...] 리터럴 안에서는 구분자가 아니다
+
+// resolve: 입력 매핑 트리(맵/배열/문자열)를 scope 기준으로 평가
+func resolve(v any, scope map[string]any) (any, error) {
+	switch x := v.(type) {
+	case string:
+		return resolveString(x, scope)
+	case map[string]any:
+		out := make(map[string]any, len(x))
+		for k, vv := range x {
+			r, err := resolve(vv, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = r
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(x))
+		for i, vv := range x {
+			r, err := resolve(vv, scope)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func resolveString(s string, scope map[string]any) (any, error) {
+	t := strings.TrimSpace(s)
+	if strings.HasPrefix(t, "${") && exprEnd(t[2:]) == len(t)-3 {
+		return evalExpr(t[2:len(t)-1], scope)
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		j := exprEnd(s[i+2:])
+		if j < 0 {
+			return nil, fmt.Errorf("unterminated expression in %q", s)
+		}
+		v, err := evalExpr(s[i+2:i+2+j], scope)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(s[:i])
+		b.WriteString(stringify(v))
+		s = s[i+2+j+1:]
+	}
+}
+
+// exprEnd: "${" 뒤 식을 닫는 } 위치(리터럴 안의 }는 건너뜀), 없으면 -1
+func exprEnd(s string) int {
+	return scanTop(s, func(i int) bool { return s[i] == '}' })
+}
+
+// scanTop: 따옴표 문자열('...', "...")과 {...}/[...] 밖의 위치마다 at(i)를 불러 처음 true인 i, 없으면 -1
+func scanTop(s string, at func(i int) bool) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		case c == '\'' || c == '"':
+			quote = c
+			continue
+		case c == '{' || c == '[':
+			depth++
+			continue
+		case (c == '}' || c == ']') && depth > 0:
+			depth--
+			continue
+		}
+		if depth == 0 && at(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitTop: 리터럴 밖의 sep로 나누기
+func splitTop(s, sep string) []string {
+	var parts []string
+	for {
+		rest := s
+		i := scanTop(rest, func(i int) bool { return strings.HasPrefix(rest[i:], sep) })
+		if i < 0 {
+			return append(parts, s)
+		}
+		parts = append(parts, s[:i])
+		s = s[i+len(sep):]
+	}
+}
+
+// evalExpr: "a ?? b ?? c | filter"
+func evalExpr(expr string, scope map[string]any) (any, error) {
+	parts := splitTop(expr, "|")
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("only one filter is allowed in %q", expr)
+	}
+	var v any
+	for _, alt := range splitTop(parts[0], "??") {
+		v = operand(strings.TrimSpace(alt), scope)
+		if !isEmpty(v) {
+			break
+		}
+	}
+	if len(parts) == 1 {
+		return v, nil
+	}
+	switch f := strings.TrimSpace(parts[1]); f {
+	case "string":
+		return stringify(v), nil
+	case "json":
+		b, _ := json.Marshal(v)
+		return json.RawMessage(b), nil
+	default:
+		return nil, fmt.Errorf("unknown filter %q", f)
+	}
+}
+
+// operand: JSON 리터럴(숫자/문자열/true/null/{}/[]) 또는 경로
+func operand(tok string, scope map[string]any) any {
+	var lit any
+	if tok != "" && json.Unmarshal([]byte(tok), &lit) == nil {
+		return lit
+	}
+	if strings.HasPrefix(tok, "'") && strings.HasSuffix(tok, "'") && len(tok) >= 2 {
+		return tok[1 : len(tok)-1]
+	}
+	if isIdent(tok) {
+		return lookup(scope, tok)
+	}
+	return tok // 따옴표 없는 상수(예: SUCCEEDED)
+}
+
+func isIdent(tok string) bool {
+	root, _, _ := strings.Cut(tok, ".")
+	return root == "input" || root == "meta" || root == "session" || root == "steps" || root == "result"
+}
+
+func lookup(scope map[string]any, path string) any {
+	var cur any = scope
+	for _, p := range strings.Split(path, ".") {
+		switch x := cur.(type) {
+		case map[string]any:
+			if p == "#" {
+				return float64(len(x))
+			}
+			cur = x[p]
+		case []any:
+			if p == "#" {
+				return float64(len(x))
+			}
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(x) {
+				return nil
+			}
+			cur = x[i]
+		case string:
+			if p == "#" {
+				return float64(len(x))
+			}
+			return nil
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// evalCond: when 조건 평가 (빈 조건은 true)
+func evalCond(cond string, scope map[string]any) bool {
+	if strings.TrimSpace(cond) == "" {
+		return true
+	}
+	for _, or := range splitTop(cond, "||") {
+		all := true
+		for _, and := range splitTop(or, "&&") {
+			if !evalAtom(strings.TrimSpace(and), scope) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return true
+		}
+	}
+	return false
+}
+
+var compareOps = []string{"==", "!=", ">=", "<=", ">", "<"} // 긴 연산자 먼저
+
+func evalAtom(atom string, scope map[string]any) bool {
+	for _, op := range compareOps {
+		if i := scanTop(atom, func(i int) bool { return strings.HasPrefix(atom[i:], op) }); i >= 0 {
+			l, r := atom[:i], atom[i+len(op):]
+			return compare(operand(strings.TrimSpace(l), scope), op, operand(strings.TrimSpace(r), scope))
+		}
+	}
+	if strings.HasPrefix(atom, "!") {
+		return isEmpty(operand(strings.TrimSpace(atom[1:]), scope))
+	}
+	return !isEmpty(operand(atom, scope))
+}
+
+func compare(a any, op string, b any) bool {
+	fa, okA := a.(float64)
+	fb, okB := b.(float64)
+	if okA && okB {
+		switch op {
+		case "==":
+			return fa == fb
+		case "!=":
+			return fa != fb
+		case ">":
+			return fa > fb
+		case "<":
+			return fa < fb
+		case ">=":
+			return fa >= fb
+		case "<=":
+			return fa <= fb
+		}
+	}
+	eq := reflect.DeepEqual(a, b) || stringify(a) == stringify(b)
+	switch op {
+	case "==":
+		return eq
+	case "!=":
+		return !eq
+	}
+	return false
+}
+
+func isEmpty(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !x
+	case string:
+		return x == ""
+	case map[string]any:
+		return len(x) == 0
+	case []any:
+		return len(x) == 0
+	}
+	return false
+}
+
+func stringify(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	default:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+}
+
+// toGeneric: 구조체/RawMessage → map/[]any 형태로(경로 조회용)
+func toGeneric(v any) any {
+	var b []byte
+	switch x := v.(type) {
+	case json.RawMessage:
+		b = x
+	default:
+		b, _ = json.Marshal(x)
+	}
+	var out any
+	if len(b) == 0 || json.Unmarshal(b, &out) != nil {
+		return nil
+	}
+	return out
+}
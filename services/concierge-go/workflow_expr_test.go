@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestResolveLiterals(t *testing.T) {
+	scope := map[string]any{
+		"input": map[string]any{"a": "x", "n": 3.0, "list": []any{1.0, 2.0}},
+		"meta":  map[string]any{},
+	}
+	cases := []struct {
+		expr string
+		want any
+	}{
+		{"${input.a}", "x"},
+		{"${meta.missing ?? 'a|b'}", "a|b"},
+		{"${meta.missing ?? 'x ?? y'}", "x ?? y"},
+		{`${meta.missing ?? "}"}`, "}"},
+		{"${meta.missing ?? {\"k\": \"a|b}\"}}", map[string]any{"k": "a|b}"}},
+		{`${meta.missing ?? ["?", "|"] | json}`, json.RawMessage(`["?","|"]`)},
+		{"${input.list | string}", "[1,2]"},
+		{"id: ${input.a}, pipe: ${meta.missing ?? '|'}!", "id: x, pipe: |!"},
+		{"${input.n ?? 0}", 3.0},
+	}
+	for _, c := range cases {
+		got, err := resolveString(c.expr, scope)
+		if err != nil {
+			t.Errorf("%s: %v", c.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s = %#v, want %#v", c.expr, got, c.want)
+		}
+	}
+	if _, err := resolveString("${input.a | string | json}", scope); err == nil {
+		t.Error("two filters: want error")
+	}
+	if _, err := resolveString("${input.a ?? '}'", scope); err == nil {
+		t.Error("unterminated: want error")
+	}
+}
+
+func TestEvalCondLiterals(t *testing.T) {
+	scope := map[string]any{"input": map[string]any{"note": "a||b", "op": "x==y", "n": 2.0}}
+	cases := []struct {
+		cond string
+		want bool
+	}{
+		{"input.note == 'a||b'", true},
+		{"input.note != 'a||b' || input.n > 1", true},
+		{"input.op == 'x==y' && input.n >= 2", true},
+		{"input.note == 'a&&b'", false},
+		{"!input.missing && input.n < 3", true},
+		{"", true},
+	}
+	for _, c := range cases {
+		if got := evalCond(c.cond, scope); got != c.want {
+			t.Errorf("%q = %v, want %v", c.cond, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fakeCarrier: task_type별 응답을 정해 두고 받은 요청을 기록하는 캐리어(동기 처리)
+type fakeCarrier struct {
+	mu    sync.Mutex
+	reply map[string]func(in json.RawMessage) (any, *a2a.ErrorPayload)
+	got   []a2a.CreateTask
+	tasks map[string]*a2a.Task
+}
+
+func newFakeCarrier(t *testing.T, reply map[string]func(json.RawMessage) (any, *a2a.ErrorPayload)) (*fakeCarrier, string) {
+	fc := &fakeCarrier{reply: reply, tasks: map[string]*a2a.Task{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /tasks", func(w http.ResponseWriter, r *http.Request) {
+		var ct a2a.CreateTask
+		json.NewDecoder(r.Body).Decode(&ct)
+		t := &a2a.Task{TaskID: a2a.NewID("t_"), TaskType: ct.TaskType, Status: a2a.StatusSucceeded}
+		if f, ok := fc.reply[ct.TaskType]; !ok {
+			t.Status, t.Error = a2a.StatusFailed, a2a.NewError(a2a.ErrValidationFailed, "unsupported task_type")
+		} else if res, err := f(ct.Input); err != nil {
+			t.Status, t.Error = a2a.StatusFailed, err
+		} else {
+			t.Result, _ = json.Marshal(res)
+		}
+		fc.mu.Lock()
+		fc.got = append(fc.got, ct)
+		fc.tasks[t.TaskID] = t
+		fc.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"task_id": t.TaskID, "status": t.Status})
+	})
+	mux.HandleFunc("GET /tasks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fc.mu.Lock()
+		t := fc.tasks[r.PathValue("id")]
+		fc.mu.Unlock()
+		json.NewEncoder(w).Encode(t)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return fc, srv.URL
+}
+
+func (fc *fakeCarrier) received(taskType string) []a2a.CreateTask {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	var out []a2a.CreateTask
+	for _, ct := range fc.got {
+		if ct.TaskType == taskType {
+			out = append(out, ct)
+		}
+	}
+	return out
+}
+
+func notFound(json.RawMessage) (any, *a2a.ErrorPayload) {
+	return nil, a2a.NewError(a2a.ErrNotFound, "unknown tracking_id")
+}
+
+func putTask(t *testing.T, ct a2a.CreateTask) string {
+	t.Helper()
+	id := a2a.NewID("t_")
+	if err := tasks.Put(&a2a.Task{TaskID: id, TaskType: ct.TaskType, Status: a2a.StatusPending, CreatedAt: time.Now().UTC(), Request: &ct}); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// 내장 BOOK: SHIP 뒤 TRACK이 실패하면 만든 라벨을 VOID_SHIPMENT로 되돌리고, 끝내 안 되면 ESCALATED
+func TestBookSagaAfterShip(t *testing.T) {
+	tests := []struct {
+		name     string
+		void     func(json.RawMessage) (any, *a2a.ErrorPayload)
+		status   a2a.SagaStatus
+		attempts int
+	}{
+		{"compensated", func(json.RawMessage) (any, *a2a.ErrorPayload) { return map[string]any{"status": "VOIDED"}, nil }, a2a.SagaCompensated, 1},
+		{"escalated", func(json.RawMessage) (any, *a2a.ErrorPayload) {
+			return nil, a2a.NewError(a2a.ErrUnavailable, "void backend down")
+		}, a2a.SagaEscalated, 3},
+	}
+	old := carriers
+	t.Cleanup(func() { carriers = old })
+	for _, tt := range tests {
+		fc, url := newFakeCarrier(t, map[string]func(json.RawMessage) (any, *a2a.ErrorPayload){
+			"QUOTE": func(json.RawMessage) (any, *a2a.ErrorPayload) {
+				return map[string]any{"carrier": "Fake", "price": 10000, "currency": "KRW", "eta_days": 3}, nil
+			},
+			"SHIP": func(json.RawMessage) (any, *a2a.ErrorPayload) {
+				return map[string]any{"tracking_id": "F-123", "status": "LABEL_CREATED"}, nil
+			},
+			"TRACK":         notFound,
+			"VOID_SHIPMENT": tt.void,
+		})
+		carriers = []string{url}
+
+		wfs, err := loadWorkflows()
+		if err != nil {
+			t.Fatal(err)
+		}
+		book := wfs["BOOK"]
+		c := book.Compensations["SHIP"]
+		c.MaxAttempts, c.Backoff = 3, time.Millisecond
+		book.Compensations["SHIP"] = c
+		ct := a2a.CreateTask{TaskType: "BOOK", Input: json.RawMessage(`{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":1}}`)}
+		id := putTask(t, ct)
+		res := book.Run(context.Background(), id, ct)
+
+		if res.Status != a2a.StatusFailed || res.Error == nil || res.Error.Code != a2a.ErrNotFound {
+			t.Fatalf("%s: status = %s, error = %+v", tt.name, res.Status, res.Error)
+		}
+		// 저장된 saga 기록: 재시도(RETRYING) 뒤 마지막이 최종 상태
+		stored, _ := tasks.Get(id)
+		for _, saga := range [][]a2a.SagaEntry{res.Saga, stored.Saga} {
+			if len(saga) != tt.attempts {
+				t.Fatalf("%s: saga = %+v", tt.name, saga)
+			}
+			last := saga[len(saga)-1]
+			if last.StepID != "ship" || last.TaskType != "VOID_SHIPMENT" || last.Status != tt.status || last.Attempt != tt.attempts {
+				t.Errorf("%s: last saga entry = %+v", tt.name, last)
+			}
+			for _, e := range saga[:len(saga)-1] {
+				if e.Status != a2a.SagaRetrying || e.Error == nil || e.Error.Code != a2a.ErrUnavailable {
+					t.Errorf("%s: retry entry = %+v", tt.name, e)
+				}
+			}
+		}
+		voids := fc.received("VOID_SHIPMENT")
+		if len(voids) != tt.attempts || !strings.Contains(string(voids[0].Input), `"F-123"`) {
+			t.Errorf("%s: VOID_SHIPMENT requests = %+v", tt.name, voids)
+		}
+		for _, v := range voids {
+			if v.IdempotencyKey != voids[0].IdempotencyKey || v.IdempotencyKey == "" {
+				t.Errorf("%s: retries use idempotency keys %q, %q", tt.name, voids[0].IdempotencyKey, v.IdempotencyKey)
+			}
+		}
+		if tr := fc.received("TRACK"); len(tr) != 1 || tr[0].ReplyURL != "" {
+			t.Errorf("%s: TRACK requests = %+v (want one, without reply_url)", tt.name, tr)
+		}
+	}
+}
+
+// ctx가 끝나면 보상 재시도 대기를 멈추고 ESCALATED로 기록한다
+func TestCompensateStopsWhenContextEnds(t *testing.T) {
+	fc, url := newFakeCarrier(t, map[string]func(json.RawMessage) (any, *a2a.ErrorPayload){
+		"VOID_SHIPMENT": func(json.RawMessage) (any, *a2a.ErrorPayload) { return nil, a2a.NewError(a2a.ErrUnavailable, "down") },
+	})
+	wf := &Workflow{
+		Agents:        map[string]string{"fake": url},
+		Compensations: map[string]Compensation{"SHIP": {TaskType: "VOID_SHIPMENT", Input: map[string]any{"tracking_id": "${result.tracking_id}"}, MaxAttempts: 10, Backoff: time.Hour}},
+	}
+	task := &a2a.Task{Steps: []a2a.StepRun{{StepID: "ship", TaskType: "SHIP", Agent: "fake", Status: a2a.StatusSucceeded, Result: json.RawMessage(`{"tracking_id":"F-1"}`)}}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var saga []a2a.SagaEntry
+	start := time.Now()
+	wf.compensate(ctx, "t_1", task, []int{0}, func(e a2a.SagaEntry) { saga = append(saga, e) })
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("compensate waited %v after ctx ended", d)
+	}
+	if len(saga) == 0 || saga[len(saga)-1].Status != a2a.SagaEscalated {
+		t.Errorf("saga = %+v", saga)
+	}
+	if n := len(fc.received("VOID_SHIPMENT")); n != 1 {
+		t.Errorf("VOID_SHIPMENT sent %d times, want 1", n)
+	}
+}
+
+// 재개한 실행에서도 이전 실행에 끝난 단계의 입력으로 보상 입력을 만든다
+func TestCompensationUsesInputsOfResumedSteps(t *testing.T) {
+	fc, url := newFakeCarrier(t, map[string]func(json.RawMessage) (any, *a2a.ErrorPayload){
+		"TRACK":         notFound,
+		"VOID_SHIPMENT": func(json.RawMessage) (any, *a2a.ErrorPayload) { return map[string]any{"status": "VOIDED"}, nil },
+	})
+	var wf Workflow
+	err := yaml.Unmarshal([]byte(`
+name: test
+trigger: TEST
+agents: {fake: "`+url+`"}
+steps:
+  - {id: ship, task_type: SHIP, agent: fake, input: {reason: "${input.reason}"}}
+  - {id: track, task_type: TRACK, agent: fake, depends_on: [ship], input: {tracking_id: "${steps.ship.result.tracking_id}"}}
+compensations:
+  SHIP:
+    task_type: VOID_SHIPMENT
+    input: {tracking_id: "${result.tracking_id}", reason: "${input.reason}"}
+    max_attempts: 1
+`), &wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wf.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ct := a2a.CreateTask{TaskType: "TEST", Input: json.RawMessage(`{"reason":"changed after resume"}`)}
+	id := putTask(t, ct)
+	done := time.Now().UTC()
+	tasks.Update(id, func(cur *a2a.Task) error {
+		cur.Status = a2a.StatusInputRequired
+		cur.Steps = []a2a.StepRun{
+			{StepID: "ship", TaskType: "SHIP", Agent: "fake", Status: a2a.StatusSucceeded, FinishedAt: &done,
+				Input: json.RawMessage(`{"reason":"original"}`), Result: json.RawMessage(`{"tracking_id":"F-9"}`)},
+			{StepID: "track", TaskType: "TRACK", Status: a2a.StatusPending},
+		}
+		return nil
+	})
+
+	res := wf.Run(context.Background(), id, ct)
+	if res.Status != a2a.StatusFailed || len(res.Saga) != 1 || res.Saga[0].Status != a2a.SagaCompensated {
+		t.Fatalf("status = %s, saga = %+v", res.Status, res.Saga)
+	}
+	if len(fc.received("SHIP")) != 0 {
+		t.Error("completed step was run again")
+	}
+	voids := fc.received("VOID_SHIPMENT")
+	if len(voids) != 1 || !strings.Contains(string(voids[0].Input), `"original"`) || !strings.Contains(string(voids[0].Input), `"F-9"`) {
+		t.Errorf("VOID_SHIPMENT requests = %+v", voids)
+	}
+}
+
+// fan-out 단계의 max_wait_ms는 단계 timeout보다 길어지지 않는다
+func TestFanOutMaxWaitClampedToStepTimeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	old := carriers
+	carriers = []string{slow.URL}
+	t.Cleanup(func() { carriers = old })
+
+	wf := &Workflow{}
+	s := Step{ID: "quote", TaskType: "QUOTE", Agent: AgentCarriers, Timeout: 100 * time.Millisecond, FanOut: &FanOutSpec{Quorum: 1}, Retry: &a2a.NoRetry}
+	start := time.Now()
+	_, err := wf.execStep(context.Background(), s, AgentCarriers, map[string]any{"max_wait_ms": 5000})
+	if err == nil {
+		t.Fatal("want quorum failure")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("step ran %v, want about its 100ms timeout", d)
+	}
+}
+
+// 단계 agent는 이름 또는 설정된 캐리어 URL만 허용 — 호출자가 넣은 임의 URL로는 보내지 않는다
+func TestDispatchRejectsUnknownAgentURLs(t *testing.T) {
+	fc, url := newFakeCarrier(t, map[string]func(json.RawMessage) (any, *a2a.ErrorPayload){
+		"PING": func(json.RawMessage) (any, *a2a.ErrorPayload) { return map[string]any{"ok": true}, nil },
+	})
+	old := carriers
+	carriers = []string{url}
+	t.Cleanup(func() { carriers = old })
+	wf := &Workflow{Agents: map[string]string{"fake": url}}
+	ct := a2a.CreateTask{TaskType: "PING", Input: json.RawMessage(`{}`)}
+
+	for _, agent := range []string{"fake", url, url + "/"} {
+		if _, err := wf.dispatch(context.Background(), agent, ct, nil, &a2a.NoRetry); err != nil {
+			t.Errorf("%s: %v", agent, err)
+		}
+	}
+	for _, agent := range []string{"http://169.254.169.254/latest", "https://example.com", "nope"} {
+		_, err := wf.dispatch(context.Background(), agent, ct, nil, &a2a.NoRetry)
+		var ep *a2a.ErrorPayload
+		if !errors.As(err, &ep) || ep.Code != a2a.ErrValidationFailed {
+			t.Errorf("%s: err = %v, want VALIDATION_FAILED", agent, err)
+		}
+	}
+	if n := len(fc.received("PING")); n != 3 {
+		t.Errorf("carrier received %d requests, want 3", n)
+	}
+}
+
+// 이미 끝난 task로는 워크플로를 시작하지 않는다(상태 기계가 거절한 전이를 무시하고 진행하지 않음)
+func TestRunRefusesTerminalTask(t *testing.T) {
+	fc, url := newFakeCarrier(t, map[string]func(json.RawMessage) (any, *a2a.ErrorPayload){
+		"PING": func(json.RawMessage) (any, *a2a.ErrorPayload) { return map[string]any{"ok": true}, nil },
+	})
+	wf := &Workflow{Name: "test", Agents: map[string]string{"fake": url}, Steps: []Step{{ID: "ping", TaskType: "PING", Agent: "fake"}}}
+	ct := a2a.CreateTask{TaskType: "TEST", Input: json.RawMessage(`{}`)}
+	id := putTask(t, ct)
+	tasks.Update(id, func(cur *a2a.Task) error { return cur.SetStatus(a2a.StatusSucceeded, a2a.Audit{}, "done") })
+
+	res := wf.Run(context.Background(), id, ct)
+	if res.Status != a2a.StatusFailed || res.Error == nil || res.Error.Code != a2a.ErrConflict {
+		t.Errorf("status = %s, error = %+v", res.Status, res.Error)
+	}
+	if len(fc.received("PING")) != 0 {
+		t.Error("step ran for a finished task")
+	}
+	if cur, _ := tasks.Get(id); cur.Status != a2a.StatusSucceeded {
+		t.Errorf("stored status = %s", cur.Status)
+	}
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ====== LLM 해석 캐시 ======
+//
+// 같은 발화(정규화 후)는 LLM을 다시 부르지 않는다. 키 = 모델 이름/모드 + 프롬프트 버전 + 발화 —
+// 모델이나 프롬프트가 바뀌면 예전 항목은 자연히 안 맞는다.
+// 크기(LRU)와 TTL로 제한하고, 동시에 들어온 같은 요청은 singleflight로 한 번만 부른다.
+//
+// INTERPRET_CACHE_SIZE(기본 1000, 0이면 끔) / INTERPRET_CACHE_TTL(기본 1h) /
+// INTERPRET_CACHE_FILE(비어 있으면 메모리만 — 있으면 JSON lines로 이어 쓰고 시작할 때 읽는다.
+// 덮어쓰기/밀려남/만료로 죽은 줄이 살아 있는 항목보다 많아지면 그 자리에서 다시 쓴다)
+
+// promptVersion: 두 모드의 프롬프트 + 도구 스키마 해시 앞 8자리
+var promptVersion = func() string {
+	schema, _ := json.Marshal(quoteTool.Parameters)
+	h := sha256.Sum256([]byte(quotePrompt + "\x00" + toolPrompt + "\x00" + string(schema)))
+	return hex.EncodeToString(h[:4])
+}()
+
+// llmResult: interpretWithLLM 결과(err 없는 것만 캐시)
+type llmResult struct {
+	Quote   QuoteInput   `json:"quote"`
+	Invalid []fieldError `json:"invalid,omitempty"`
+}
+
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Value   llmResult `json:"value"`
+	Expires time.Time `json:"expires"`
+}
+
+// CacheStats: GET /metrics
+type CacheStats struct {
+	Size      int    `json:"size"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Coalesced uint64 `json:"coalesced"` // 진행 중인 같은 요청을 기다려 받은 것
+	Evictions uint64 `json:"evictions"` // 크기 초과로 밀려난 것
+	Expired   uint64 `json:"expired"`
+	Prompt    string `json:"prompt_version"`
+}
+
+type llmCache struct {
+	mu    sync.Mutex
+	max   int
+	ttl   time.Duration
+	ll    *list.List // 앞쪽이 최근
+	items map[string]*list.Element
+	file  *os.File
+	path  string
+	lines int // 파일의 줄 수(죽은 줄 포함) — 압축 시점 판단용
+	stats CacheStats
+
+	sf singleflight.Group
+}
+
+var cache = newCacheFromEnv()
+
+func newCacheFromEnv() *llmCache {
+	size, _ := strconv.Atoi(getenv("INTERPRET_CACHE_SIZE", "1000"))
+	ttl, err := time.ParseDuration(getenv("INTERPRET_CACHE_TTL", "1h"))
+	if err != nil {
+		log.Println("[Interpreter] bad INTERPRET_CACHE_TTL → 1h:", err)
+		ttl = time.Hour
+	}
+	c := newCache(size, ttl)
+	if path := os.Getenv("INTERPRET_CACHE_FILE"); path != "" && size > 0 {
+		if err := c.open(path); err != nil {
+			log.Println("[Interpreter] cache file disabled:", err)
+		}
+	}
+	return c
+}
+
+func newCache(size int, ttl time.Duration) *llmCache {
+	return &llmCache{max: size, ttl: ttl, ll: list.New(), items: map[string]*list.Element{}}
+}
+
+// cacheKey: 모델 + 프롬프트 버전 + 정규화한 발화
+func cacheKey(model, utterance string) string {
+	h := sha256.Sum256([]byte(model + "\x00" + promptVersion + "\x00" + normalizeUtterance(utterance)))
+	return hex.EncodeToString(h[:])
+}
+
+// normalizeUtterance: 소문자, 줄마다 공백 하나로, 빈 줄 제거(줄 구분은 후속 답이라 유지)
+func normalizeUtterance(s string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ToLower(s), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// interpretCached: interpretWithLLM 앞단. 캐시 → 진행 중인 같은 요청 → LLM 순.
+func interpretCached(ctx context.Context, p LLMProvider, utterance string) (QuoteInput, []fieldError, error) {
+	if cache.max <= 0 {
+		return interpretWithLLM(ctx, p, utterance)
+	}
+	key := cacheKey(p.Name()+"/"+modeOf(p), utterance)
+	if v, ok := cache.get(key); ok {
+		return cloneQuote(v.Quote), v.Invalid, nil
+	}
+	// 먼저 온 요청이 취소돼도 기다리는 쪽이 같이 실패하지 않게 취소만 떼어 낸다(타임아웃은 공급자 쪽)
+	lctx := context.WithoutCancel(ctx)
+	called := false
+	ch := cache.sf.DoChan(key, func() (any, error) {
+		called = true
+		q, invalid, err := interpretWithLLM(lctx, p, utterance)
+		if err != nil {
+			return nil, err
+		}
+		v := llmResult{Quote: q, Invalid: invalid}
+		cache.put(key, v)
+		return v, nil
+	})
+	select {
+	case <-ctx.Done():
+		return QuoteInput{}, nil, ctx.Err()
+	case r := <-ch:
+		if r.Shared && !called {
+			cache.count(func(s *CacheStats) { s.Coalesced++ })
+		}
+		if r.Err != nil {
+			return QuoteInput{}, nil, r.Err
+		}
+		v := r.Val.(llmResult)
+		return cloneQuote(v.Quote), v.Invalid, nil
+	}
+}
+
+// cloneQuote: 캐시 값은 여러 요청이 나눠 쓰므로(setPath/applyDefaults가 map을 고친다) 복사해서 준다
+func cloneQuote(q QuoteInput) QuoteInput {
+	var out QuoteInput
+	b, _ := json.Marshal(q)
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+func (c *llmCache) count(f func(*CacheStats)) {
+	c.mu.Lock()
+	f(&c.stats)
+	c.mu.Unlock()
+}
+
+func (c *llmCache) get(key string) (llmResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*cacheEntry)
+		if time.Now().Before(e.Expires) {
+			c.ll.MoveToFront(el)
+			c.stats.Hits++
+			return e.Value, true
+		}
+		c.remove(el)
+		c.stats.Expired++
+	}
+	c.stats.Misses++
+	return llmResult{}, false
+}
+
+func (c *llmCache) put(key string, v llmResult) {
+	e := &cacheEntry{Key: key, Value: v, Expires: time.Now().Add(c.ttl)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insert(e)
+	if c.file != nil {
+		if b, err := json.Marshal(e); err == nil {
+			_, _ = c.file.Write(append(b, '\n'))
+			c.lines++
+		}
+		if c.lines >= compactMinLines && c.lines > compactRatio*c.ll.Len() {
+			if err := c.compact(); err != nil {
+				log.Println("[Interpreter] cache compaction failed (file writes stop if it could not reopen):", err)
+			}
+		}
+	}
+}
+
+// 파일 줄 수가 살아 있는 항목의 compactRatio배를 넘으면(그리고 compactMinLines 이상이면) 다시 쓴다
+const (
+	compactRatio    = 2
+	compactMinLines = 64
+)
+
+// insert: 같은 키는 덮어쓰고, 넘치면 가장 오래 안 쓴 것부터 내보낸다(mu 잡은 채로)
+func (c *llmCache) insert(e *cacheEntry) {
+	if el, ok := c.items[e.Key]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[e.Key] = c.ll.PushFront(e)
+	for c.ll.Len() > c.max {
+		c.remove(c.ll.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *llmCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*cacheEntry).Key)
+}
+
+func (c *llmCache) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Size, s.Prompt = c.ll.Len(), promptVersion
+	return s
+}
+
+// ---- 영속 캐시(JSON lines) ----------------------------------------------------------
+
+// open: 파일을 읽어 살아 있는 항목만 올리고, 그것만 남도록 다시 쓴 뒤 이어 쓰기로 연다
+func (c *llmCache) open(path string) error {
+	if f, err := os.Open(path); err == nil {
+		now := time.Now()
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+		for sc.Scan() {
+			var e cacheEntry
+			if json.Unmarshal(sc.Bytes(), &e) != nil || e.Key == "" || !now.Before(e.Expires) {
+				continue // 깨진 줄(쓰다 끊긴 마지막 줄 등)과 만료된 항목은 버린다
+			}
+			c.insert(&e)
+		}
+		f.Close()
+		c.stats.Evictions = 0
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	c.path = path
+	if err := c.compact(); err != nil {
+		return err
+	}
+	log.Printf("[Interpreter] cache file %s: %d entries", path, c.ll.Len())
+	return nil
+}
+
+// compact: 살아 있는 항목만 임시 파일에 써서 바꿔 끼우고 이어 쓰기로 다시 연다(mu 잡은 채로, open에서는 잠금 전).
+// 오래된 것부터 써야 다시 읽을 때 LRU 순서가 유지된다.
+func (c *llmCache) compact() error {
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	n := 0
+	now := time.Now()
+	for el := c.ll.Back(); el != nil; el = el.Prev() {
+		if e := el.Value.(*cacheEntry); !now.Before(e.Expires) {
+			continue
+		}
+		b, _ := json.Marshal(el.Value)
+		w.Write(append(b, '\n'))
+		n++
+	}
+	if err := errors.Join(w.Flush(), f.Close()); err != nil {
+		return err
+	}
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	if c.file, err = os.OpenFile(c.path, os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+		return err
+	}
+	c.lines = n
+	return nil
+}
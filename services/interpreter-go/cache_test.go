@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func entry(country string) llmResult {
+	q := QuoteInput{}
+	setPath(&q, "to.country", country)
+	return llmResult{Quote: q}
+}
+
+func TestCacheTTL(t *testing.T) {
+	c := newCache(10, 30*time.Millisecond)
+	c.put("k", entry("US"))
+	if v, ok := c.get("k"); !ok || getPath(v.Quote, "to.country") != "US" {
+		t.Fatalf("get = %+v, %v", v, ok)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := c.get("k"); ok {
+		t.Fatal("expired entry returned")
+	}
+	s := c.snapshot()
+	if s.Hits != 1 || s.Misses != 1 || s.Expired != 1 || s.Size != 0 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	c := newCache(2, time.Hour)
+	c.put("a", entry("KR"))
+	c.put("b", entry("US"))
+	c.get("a") // a가 최근 — 다음에 밀려나는 건 b
+	c.put("c", entry("JP"))
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry kept")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.get(k); !ok {
+			t.Errorf("%s evicted", k)
+		}
+	}
+	c.put("a", entry("CN")) // 같은 키 덮어쓰기는 밀어내지 않음
+	if s := c.snapshot(); s.Evictions != 1 || s.Size != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+// blockingProvider: release가 닫힐 때까지 응답을 미루고 호출 수를 센다
+type blockingProvider struct {
+	mu      sync.Mutex
+	calls   int
+	release chan struct{}
+}
+
+func (p *blockingProvider) Name() string { return "blocking" }
+func (p *blockingProvider) Complete(ctx context.Context, _ LLMRequest) (string, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	<-p.release
+	return `{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":1}}`, nil
+}
+
+func TestCacheCoalescing(t *testing.T) {
+	old := cache
+	cache = newCache(10, time.Hour)
+	t.Cleanup(func() { cache = old })
+	p := &blockingProvider{release: make(chan struct{})}
+
+	const n = 5
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q, _, err := interpretCached(context.Background(), p, "서울에서 LA로 1kg")
+			if err != nil || getPath(q, "to.country") != "US" {
+				t.Errorf("q=%+v err=%v", q, err)
+			}
+		}()
+	}
+	// 모두 진행 중인 호출에 붙을 때까지 기다렸다가 풀어 준다
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if s := cache.snapshot(); s.Misses == n || time.Now().After(deadline) {
+			break
+		}
+	}
+	close(p.release)
+	wg.Wait()
+
+	if _, _, err := interpretCached(context.Background(), p, "  서울에서   LA로 1KG "); err != nil { // 정규화 후 같은 발화
+		t.Fatal(err)
+	}
+	s := cache.snapshot()
+	if p.calls != 1 || s.Misses != n || s.Coalesced != n-1 || s.Hits != 1 || s.Size != 1 {
+		t.Errorf("calls = %d, stats = %+v", p.calls, s)
+	}
+}
+
+func TestCacheFileCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	c := newCache(4, time.Hour)
+	if err := c.open(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.file.Close() })
+	for i := range 200 {
+		c.put("k"+strconv.Itoa(i%8), entry("US"))
+	}
+	if lines := countLines(t, path); lines > compactMinLines+compactRatio*4 {
+		t.Errorf("file has %d lines for %d live entries", lines, c.ll.Len())
+	}
+
+	// 다시 열면 살아 있는 항목이 같은 LRU 순서로 돌아온다
+	c2 := newCache(4, time.Hour)
+	if err := c2.open(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c2.file.Close() })
+	if c2.ll.Len() != 4 || c2.ll.Front().Value.(*cacheEntry).Key != c.ll.Front().Value.(*cacheEntry).Key {
+		t.Errorf("reopened %d entries", c2.ll.Len())
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	a2a "a2a/contract"
+)
+
+// ====== 되묻기(INPUT_REQUIRED) ======
+//
+// 출발/도착 국가와 무게는 추측하지 않는다. 빠졌거나 애매하면 task를 INPUT_REQUIRED로 두고
+// 질문을 돌려준 뒤, POST /tasks/{id}/messages로 온 답을 누적해 다시 해석한다.
+
+// session: task별 대화 누적(발화 + 구조화된 답)
+type session struct {
+	Utterances []string
+	Data       map[string]any // 필드 경로 → 값(발화 해석보다 우선)
+
+	MinConfidence float64            // 필수 필드 확신도 하한(0이면 보지 않음)
+	OnLow         string             // 하한 미달 시 ask(되묻기, 기본) | refuse(실패)
+	Asked         []a2a.FieldRequest // 직전에 물어본 필드(LOW_CONFIDENCE 확인 답 처리용)
+}
+
+// clone: 잠금 밖에서 고쳐 쓸 사본
+func (s *session) clone() *session {
+	cp := *s
+	cp.Utterances = slices.Clone(s.Utterances)
+	cp.Data = maps.Clone(s.Data)
+	cp.Asked = slices.Clone(s.Asked)
+	return &cp
+}
+
+// interpretation: 세션 해석 결과
+type interpretation struct {
+	Quote      QuoteInput
+	Provenance map[string]a2a.Provenance // 필드 경로 → 출처/확신도
+	Need       *a2a.InputRequest         // 되물을 것
+	Refused    *a2a.ErrorPayload         // 확신도 미달 + refuse 정책
+}
+
+const (
+	confDefault = 0.3 // 말하지 않아 채운 기본값
+	confLLM     = 0.7 // 규칙으로 확인할 수 없는 LLM 추론
+)
+
+// 필수 필드와 질문
+var requiredFields = []struct{ path, question string }{
+	{"from.country", "어디에서 보내시나요? (출발 국가/도시)"},
+	{"to.country", "어디로 보내시나요? (도착 국가/도시)"},
+	{"parcel.weight_kg", "소포 무게는 몇 kg인가요?"},
+}
+
+// interpretSession: 누적된 대화로 QuoteInput과 필드별 출처를 만들고,
+// 아직 확정 안 된(또는 확신이 낮은) 필수 필드가 있으면 InputRequest 반환
+func interpretSession(ctx context.Context, s *session) interpretation {
+	text := strings.Join(s.Utterances, "\n")
+	rules, ruleAmb, ruleConf := interpretRules(text)
+	var (
+		out QuoteInput
+		amb []a2a.FieldRequest
+		err error
+	)
+	prov := map[string]a2a.Provenance{}
+	if llm != nil {
+		var invalid []fieldError
+		out, invalid, err = interpretCached(ctx, llm, text)
+		if err == nil {
+			// LLM 값은 규칙 해석과 맞춰 보고 확신도를 매긴다
+			for _, path := range fieldPaths(out) {
+				prov[path] = a2a.Provenance{Source: a2a.SourceLLM, Confidence: llmConfidence(getPath(out, path), getPath(rules, path))}
+			}
+			if len(invalid) > 0 {
+				// 고치지 못한 필드만 규칙 해석으로 메운다
+				log.Println("[Interpreter] LLM output still invalid → merge with rules:", invalid)
+				out, amb = mergeRules(out, rules), ruleAmb
+			}
+		}
+	} else {
+		err = errors.New("no LLM configured")
+	}
+	if err != nil {
+		log.Println("[Interpreter] LLM failed → rules:", err)
+		out, amb = rules, ruleAmb
+	}
+	for _, path := range fieldPaths(out) {
+		if _, ok := prov[path]; !ok {
+			prov[path] = a2a.Provenance{Source: a2a.SourceRule, Confidence: ruleConf[path]}
+		}
+	}
+	for path, v := range s.Data {
+		setPath(&out, path, v)
+		prov[path] = a2a.Provenance{Source: a2a.SourceUser, Confidence: 1}
+	}
+	applyDefaults(&out)
+	for _, path := range fieldPaths(out) {
+		if _, ok := prov[path]; !ok {
+			prov[path] = a2a.Provenance{Source: a2a.SourceDefault, Confidence: confDefault}
+		}
+	}
+
+	res := interpretation{Quote: out, Provenance: prov, Need: missingFields(out, amb)}
+	if s.MinConfidence <= 0 {
+		return res
+	}
+	// 필수 필드 확신도 — refuse면 실패, 아니면 확인 질문(이미 되묻는 필드는 제외)
+	var critical []string
+	for _, rf := range requiredFields {
+		if res.Need == nil || !slices.ContainsFunc(res.Need.Fields, func(f a2a.FieldRequest) bool { return f.Field == rf.path }) {
+			critical = append(critical, rf.path)
+		}
+	}
+	low := a2a.LowConfidence(prov, critical, s.MinConfidence)
+	if len(low) == 0 {
+		return res
+	}
+	if s.OnLow == "refuse" {
+		var parts []string
+		for _, path := range low {
+			parts = append(parts, fmt.Sprintf("%s=%v (%.2f < %.2f)", path, getPath(out, path), prov[path].Confidence, s.MinConfidence))
+		}
+		res.Refused = a2a.NewError(a2a.ErrValidationFailed, "low confidence: "+strings.Join(parts, ", "))
+		return res
+	}
+	if res.Need == nil {
+		res.Need = &a2a.InputRequest{}
+	}
+	for _, path := range low {
+		v := fmt.Sprint(getPath(out, path))
+		q := fmt.Sprintf("%s는 %s(으)로 이해했는데 맞나요? 아니면 바로잡아 주세요.", fieldLabels[path], v)
+		res.Need.Fields = append(res.Need.Fields, a2a.FieldRequest{Field: path, Reason: a2a.FieldLowConfidence, Candidates: []string{v}, Question: q})
+		res.Need.Questions = append(res.Need.Questions, q)
+	}
+	return res
+}
+
+var fieldLabels = map[string]string{
+	"from.country":     "출발 국가",
+	"to.country":       "도착 국가",
+	"parcel.weight_kg": "무게(kg)",
+}
+
+// llmConfidence: 규칙 해석과 같으면 높게, 다르면 낮게, 규칙으로 모르면 중간
+func llmConfidence(v, rule any) float64 {
+	switch {
+	case rule == nil:
+		return confLLM
+	case sameValue(v, rule):
+		return confExplicit
+	}
+	return 0.4
+}
+
+func sameValue(a, b any) bool {
+	x, xok := a.(float64)
+	y, yok := b.(float64)
+	if xok && yok {
+		return math.Abs(x-y) <= 0.02*max(math.Abs(x), math.Abs(y))
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// fieldPaths: 값이 있는 필드 경로(섹션.키, currency, max_wait_ms)
+func fieldPaths(q QuoteInput) []string {
+	var out []string
+	for _, name := range []string{"from", "to", "parcel", "options"} {
+		for k, v := range *sectionOf(&q, name) {
+			if v != nil {
+				out = append(out, name+"."+k)
+			}
+		}
+	}
+	if q.Currency != "" {
+		out = append(out, "currency")
+	}
+	if q.MaxWait != 0 {
+		out = append(out, "max_wait_ms")
+	}
+	return out
+}
+
+// confirm: 직전 확인 질문(LOW_CONFIDENCE)에 "네"류로 답하면 그 값을 사용자 답으로 확정
+func (s *session) confirm(text string) bool {
+	switch strings.Trim(strings.ToLower(strings.TrimSpace(text)), ".!~ ") {
+	case "네", "예", "응", "맞아", "맞아요", "맞습니다", "yes", "y", "ok", "okay", "correct", "はい", "そうです":
+	default:
+		return false
+	}
+	ok := false
+	for _, f := range s.Asked {
+		if f.Reason == a2a.FieldLowConfidence && len(f.Candidates) > 0 {
+			if s.Data == nil {
+				s.Data = map[string]any{}
+			}
+			s.Data[f.Field] = f.Candidates[0]
+			ok = true
+		}
+	}
+	return ok
+}
+
+// structuredInput: 발화가 (부분) QuoteInput JSON이면 필드 경로 → 값으로 펼친다
+// (컨시어지는 필수 필드가 빠진 구조화 입력도 INTERPRET로 보낸다)
+func structuredInput(utterance string) map[string]any {
+	var m map[string]any
+	if json.Unmarshal([]byte(utterance), &m) != nil {
+		return nil
+	}
+	out := map[string]any{}
+	for k, v := range m {
+		if sub, ok := v.(map[string]any); ok && sectionOf(&QuoteInput{}, k) != nil {
+			for kk, vv := range sub {
+				out[k+"."+kk] = vv
+			}
+		} else if k == "currency" || k == "max_wait_ms" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// applyDefaults: 물어볼 필요 없는 값만 채운다(크기/통화/대기 시간)
+func applyDefaults(q *QuoteInput) {
+	if q.Parcel == nil {
+		q.Parcel = map[string]any{}
+	}
+	for k, v := range map[string]any{"l_cm": 30, "w_cm": 20, "h_cm": 15} {
+		if _, ok := q.Parcel[k]; !ok {
+			q.Parcel[k] = v
+		}
+	}
+	if q.Currency == "" {
+		q.Currency = "KRW"
+	}
+	if q.MaxWait == 0 {
+		q.MaxWait = 1200
+	}
+}
+
+// missingFields: 필수 필드 중 비었거나 애매한 것(데이터로 확정된 애매함은 제외)
+func missingFields(q QuoteInput, amb []a2a.FieldRequest) *a2a.InputRequest {
+	byField := map[string]a2a.FieldRequest{}
+	for _, f := range amb {
+		byField[f.Field] = f
+	}
+	req := &a2a.InputRequest{}
+	for _, rf := range requiredFields {
+		if v := getPath(q, rf.path); v != nil && v != "" && v != 0.0 {
+			continue
+		}
+		f, ok := byField[rf.path]
+		if ok {
+			// 다른 필드에 이미 쓰인 후보는 제외(예: 출발지를 data로 답한 경우)
+			f.Candidates = slices.DeleteFunc(slices.Clone(f.Candidates), func(c string) bool { return usedElsewhere(q, rf.path, c) })
+		}
+		if !ok || len(f.Candidates) == 0 {
+			f = a2a.FieldRequest{Field: rf.path, Reason: a2a.FieldMissing, Question: rf.question}
+		}
+		req.Fields = append(req.Fields, f)
+		if len(req.Questions) == 0 || req.Questions[len(req.Questions)-1] != f.Question {
+			req.Questions = append(req.Questions, f.Question)
+		}
+	}
+	if len(req.Fields) == 0 {
+		return nil
+	}
+	return req
+}
+
+func usedElsewhere(q QuoteInput, path string, v string) bool {
+	for _, rf := range requiredFields {
+		if rf.path != path && getPath(q, rf.path) == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ---- 필드 경로 ------------------------------------------------------------------
+
+func sectionOf(q *QuoteInput, name string) *map[string]any {
+	switch name {
+	case "from":
+		return &q.From
+	case "to":
+		return &q.To
+	case "parcel":
+		return &q.Parcel
+	case "options":
+		return &q.Options
+	}
+	return nil
+}
+
+// setPath: "to.country" 같은 경로로 값 설정(국가는 지명도 허용), "currency"/"max_wait_ms"도 가능
+func setPath(q *QuoteInput, path string, v any) {
+	head, key, ok := strings.Cut(path, ".")
+	if !ok {
+		b, _ := json.Marshal(map[string]any{head: v})
+		_ = json.Unmarshal(b, q)
+		return
+	}
+	m := sectionOf(q, head)
+	if m == nil {
+		return
+	}
+	if *m == nil {
+		*m = map[string]any{}
+	}
+	if str, isStr := v.(string); isStr && key == "country" {
+		if p, known := gazetteer[strings.ToLower(str)]; known {
+			v = p.code
+		} else {
+			v = strings.ToUpper(str)
+		}
+	}
+	if str, isStr := v.(string); isStr && key == "weight_kg" {
+		if f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(str), "kg"), 64); err == nil {
+			v = f
+		}
+	}
+	(*m)[key] = v
+}
+
+func getPath(q QuoteInput, path string) any {
+	head, key, _ := strings.Cut(path, ".")
+	m := sectionOf(&q, head)
+	if m == nil || *m == nil {
+		return nil
+	}
+	v := (*m)[key]
+	if n, ok := v.(int); ok {
+		return float64(n)
+	}
+	return v
+}
@@ -4,5 +4,8 @@ go 1.24.2
 
 require (
 	github.com/go-chi/chi/v5 v5.2.3 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
 	github.com/sashabaranov/go-openai v1.41.2 // indirect
+	golang.org/x/sync v0.9.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 )
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// ====== LLM 공급자 ======
+//
+// LLM_PROVIDER = openai | anthropic | ollama | stub | none.
+// 비어 있으면 예전처럼 OPENAI_API_KEY/OPENAI_BASE_URL이 있으면 openai, ANTHROPIC_API_KEY가 있으면 anthropic, 아니면 규칙 해석만.
+
+// LLMProvider: 시스템 + 사용자 메시지 한 번 → 응답 텍스트(JSON 객체를 기대)
+type LLMProvider interface {
+	Name() string
+	Complete(ctx context.Context, req LLMRequest) (string, error)
+}
+
+type LLMRequest struct {
+	System      string
+	User        string
+	Temperature float32
+}
+
+func newProvider() (LLMProvider, error) {
+	name := os.Getenv("LLM_PROVIDER")
+	if name == "" {
+		switch {
+		case os.Getenv("OPENAI_API_KEY") != "" || os.Getenv("OPENAI_BASE_URL") != "":
+			name = "openai"
+		case os.Getenv("ANTHROPIC_API_KEY") != "":
+			name = "anthropic"
+		default:
+			name = "none"
+		}
+	}
+	switch strings.ToLower(name) {
+	case "openai":
+		cfg := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
+		if base := os.Getenv("OPENAI_BASE_URL"); base != "" {
+			cfg.BaseURL = base
+		}
+		return &openAIProvider{c: openai.NewClientWithConfig(cfg), model: getenv("OPENAI_MODEL", "gpt-4o-mini")}, nil
+	case "anthropic":
+		return &anthropicProvider{
+			base:  strings.TrimSuffix(getenv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"), "/"),
+			key:   os.Getenv("ANTHROPIC_API_KEY"),
+			model: getenv("ANTHROPIC_MODEL", "claude-3-5-haiku-latest"),
+			hc:    &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	case "ollama":
+		return &ollamaProvider{
+			base:  strings.TrimSuffix(getenv("OLLAMA_URL", "http://localhost:11434"), "/"),
+			model: getenv("OLLAMA_MODEL", "llama3.1"),
+			hc:    &http.Client{Timeout: 60 * time.Second},
+		}, nil
+	case "stub":
+		if path := os.Getenv("LLM_STUB_SCRIPT"); path != "" {
+			return loadStubScript(path)
+		}
+		return newStubProvider(), nil
+	case "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown LLM_PROVIDER %q", name)
+}
+
+// ---- OpenAI 호환(/v1/chat/completions) ----------------------------------------------
+
+type openAIProvider struct {
+	c     *openai.Client
+	model string
+}
+
+func (p *openAIProvider) Name() string { return "openai:" + p.model }
+
+func (p *openAIProvider) Complete(ctx context.Context, req LLMRequest) (string, error) {
+	resp, err := p.c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: p.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: req.System},
+			{Role: openai.ChatMessageRoleUser, Content: req.User},
+		},
+		// JSON-mode: 최신 모델들은 ResponseFormat 설정 또는 시스템 지시로 JSON만 출력 유도
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+		Temperature: req.Temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("openai: no choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (p *openAIProvider) CompleteTool(ctx context.Context, req LLMRequest, tool ToolSpec) (string, error) {
+	resp, err := p.c.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: p.model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: req.System},
+			{Role: openai.ChatMessageRoleUser, Content: req.User},
+		},
+		Tools: []openai.Tool{{Type: openai.ToolTypeFunction, Function: &openai.FunctionDefinition{
+			Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters,
+		}}},
+		ToolChoice:  openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: tool.Name}},
+		Temperature: req.Temperature,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("openai: no choices")
+	}
+	msg := resp.Choices[0].Message
+	for _, tc := range msg.ToolCalls {
+		if tc.Function.Name == tool.Name {
+			return tc.Function.Arguments, nil
+		}
+	}
+	return msg.Content, nil
+}
+
+// ---- Anthropic Messages API(/v1/messages) -------------------------------------------
+
+type anthropicProvider struct {
+	base, key, model string
+	hc               *http.Client
+}
+
+func (p *anthropicProvider) Name() string { return "anthropic:" + p.model }
+
+// anthropicBlock: 응답 content 블록(text | tool_use)
+type anthropicBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+func (p *anthropicProvider) messages(ctx context.Context, req LLMRequest, extra map[string]any) ([]anthropicBlock, error) {
+	body := map[string]any{
+		"model":       p.model,
+		"max_tokens":  1024,
+		"system":      req.System,
+		"temperature": req.Temperature,
+		"messages":    []map[string]string{{"role": "user", "content": req.User}},
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	var out struct {
+		Content []anthropicBlock `json:"content"`
+	}
+	hdr := http.Header{"X-Api-Key": {p.key}, "Anthropic-Version": {"2023-06-01"}}
+	if err := postJSON(ctx, p.hc, p.base+"/v1/messages", hdr, body, &out); err != nil {
+		return nil, fmt.Errorf("anthropic: %w", err)
+	}
+	return out.Content, nil
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req LLMRequest) (string, error) {
+	blocks, err := p.messages(ctx, req, nil)
+	if err != nil {
+		return "", err
+	}
+	return anthropicText(blocks)
+}
+
+func (p *anthropicProvider) CompleteTool(ctx context.Context, req LLMRequest, tool ToolSpec) (string, error) {
+	blocks, err := p.messages(ctx, req, map[string]any{
+		"tools":       []map[string]any{{"name": tool.Name, "description": tool.Description, "input_schema": tool.Parameters}},
+		"tool_choice": map[string]string{"type": "tool", "name": tool.Name},
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, b := range blocks {
+		if b.Type == "tool_use" && b.Name == tool.Name {
+			return string(b.Input), nil
+		}
+	}
+	return anthropicText(blocks)
+}
+
+func anthropicText(blocks []anthropicBlock) (string, error) {
+	var sb strings.Builder
+	for _, c := range blocks {
+		if c.Type == "text" {
+			sb.WriteString(c.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("anthropic: empty content")
+	}
+	return sb.String(), nil
+}
+
+// ---- Ollama 스타일 로컬 서버(/api/chat) ----------------------------------------------
+
+type ollamaProvider struct {
+	base, model string
+	hc          *http.Client
+}
+
+func (p *ollamaProvider) Name() string { return "ollama:" + p.model }
+
+// ollamaMessage: /api/chat 응답 메시지(도구 인자는 문자열이 아닌 객체)
+type ollamaMessage struct {
+	Content   string `json:"content"`
+	ToolCalls []struct {
+		Function struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		} `json:"function"`
+	} `json:"tool_calls"`
+}
+
+func (p *ollamaProvider) chat(ctx context.Context, req LLMRequest, extra map[string]any) (ollamaMessage, error) {
+	body := map[string]any{
+		"model":  p.model,
+		"stream": false,
+		"messages": []map[string]string{
+			{"role": "system", "content": req.System},
+			{"role": "user", "content": req.User},
+		},
+		"options": map[string]any{"temperature": req.Temperature},
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	var out struct {
+		Message ollamaMessage `json:"message"`
+	}
+	if err := postJSON(ctx, p.hc, p.base+"/api/chat", nil, body, &out); err != nil {
+		return ollamaMessage{}, fmt.Errorf("ollama: %w", err)
+	}
+	return out.Message, nil
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, req LLMRequest) (string, error) {
+	msg, err := p.chat(ctx, req, map[string]any{"format": "json"})
+	return msg.Content, err
+}
+
+// CompleteTool: Ollama는 도구 선택을 강제할 수 없다 — 도구를 안 부르면 텍스트 답을 그대로 쓴다
+func (p *ollamaProvider) CompleteTool(ctx context.Context, req LLMRequest, tool ToolSpec) (string, error) {
+	msg, err := p.chat(ctx, req, map[string]any{"tools": []map[string]any{{
+		"type":     "function",
+		"function": map[string]any{"name": tool.Name, "description": tool.Description, "parameters": tool.Parameters},
+	}}})
+	if err != nil {
+		return "", err
+	}
+	for _, tc := range msg.ToolCalls {
+		if tc.Function.Name == tool.Name {
+			return string(tc.Function.Arguments), nil
+		}
+	}
+	return msg.Content, nil
+}
+
+// ---- stub: 네트워크 없이 결정적인 응답 -------------------------------------------------
+
+// stubProvider: 준비된 응답(script)을 차례로 돌려주고, 다 쓰면 "Utterance: ..." 부분을 규칙 해석해
+// LLM과 같은 모양의 JSON으로 돌려준다. 틀린 출력을 넣어 두면 재시도/규칙 병합 경로도 오프라인에서 돌려 볼 수 있다.
+// LLM_STUB_SCRIPT: 응답 파일(한 줄에 응답 하나). 도구 호출은 없다(LLM_MODE=tools여도 JSON 모드).
+type stubProvider struct {
+	mu       sync.Mutex
+	script   []string     // 남은 준비 응답
+	Requests []LLMRequest // 받은 요청(테스트용)
+}
+
+func newStubProvider(script ...string) *stubProvider {
+	return &stubProvider{script: script}
+}
+
+func loadStubScript(path string) (*stubProvider, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("LLM_STUB_SCRIPT: %w", err)
+	}
+	var script []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			script = append(script, line)
+		}
+	}
+	return newStubProvider(script...), nil
+}
+
+func (*stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) Complete(_ context.Context, req LLMRequest) (string, error) {
+	p.mu.Lock()
+	p.Requests = append(p.Requests, req)
+	if len(p.script) > 0 {
+		out := p.script[0]
+		p.script = p.script[1:]
+		p.mu.Unlock()
+		return out, nil
+	}
+	p.mu.Unlock()
+	text := req.User
+	if i := strings.Index(text, "Utterance: "); i >= 0 {
+		text = text[i+len("Utterance: "):]
+	}
+	text, _, _ = strings.Cut(text, repairMarker) // 재시도 요청의 직전 출력/오류 목록은 발화가 아니다
+	q, _, _ := interpretRules(text)              // 애매한 지명은 LLM 규칙대로 null
+	b, err := json.Marshal(q)
+	return string(b), err
+}
+
+// ---- HTTP ----------------------------------------------------------------------
+
+func postJSON(ctx context.Context, hc *http.Client, url string, hdr http.Header, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	for k, vs := range hdr {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jsonObject: 코드 펜스나 앞뒤 설명이 붙은 응답에서 JSON 객체 부분만
+func jsonObject(txt string) string {
+	i, j := strings.Index(txt, "{"), strings.LastIndex(txt, "}")
+	if i < 0 || j < i {
+		return txt
+	}
+	return txt[i : j+1]
+}
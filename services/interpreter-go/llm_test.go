@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	a2a "a2a/contract"
+)
+
+// withStub: 전역 llm을 준비된 응답의 stub으로 바꾸고 캐시는 끈다
+func withStub(t *testing.T, script ...string) *stubProvider {
+	t.Helper()
+	p := newStubProvider(script...)
+	oldLLM, oldCache := llm, cache
+	llm, cache = p, &llmCache{}
+	t.Cleanup(func() { llm, cache = oldLLM, oldCache })
+	return p
+}
+
+func TestInterpretWithLLMValid(t *testing.T) {
+	p := withStub(t, "```json\n"+`{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":2},"currency":"USD"}`+"\n```")
+	q, invalid, err := interpretWithLLM(context.Background(), p, "서울에서 미국으로 2kg")
+	if err != nil || len(invalid) > 0 {
+		t.Fatalf("err=%v invalid=%v", err, invalid)
+	}
+	if getPath(q, "from.country") != "KR" || getPath(q, "to.country") != "US" || getPath(q, "parcel.weight_kg") != 2.0 || q.Currency != "USD" {
+		t.Errorf("quote = %+v", q)
+	}
+	if len(p.Requests) != 1 || p.Requests[0].System != quotePrompt {
+		t.Errorf("requests = %d", len(p.Requests))
+	}
+}
+
+func TestInterpretWithLLMRepaired(t *testing.T) {
+	p := withStub(t,
+		`{"from":{"country":"Korea"},"to":{"country":"US"},"parcel":{"weight_kg":-1},"currency":"WON"}`,
+		`{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":1.5},"currency":"KRW"}`,
+	)
+	q, invalid, err := interpretWithLLM(context.Background(), p, "한국에서 미국으로 1.5kg")
+	if err != nil || len(invalid) > 0 {
+		t.Fatalf("err=%v invalid=%v", err, invalid)
+	}
+	if getPath(q, "from.country") != "KR" || getPath(q, "parcel.weight_kg") != 1.5 || q.Currency != "KRW" {
+		t.Errorf("quote = %+v", q)
+	}
+	if len(p.Requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(p.Requests))
+	}
+	repair := p.Requests[1].User
+	for _, want := range []string{"Utterance: 한국에서 미국으로 1.5kg", repairMarker, "from.country", "parcel.weight_kg", "currency"} {
+		if !strings.Contains(repair, want) {
+			t.Errorf("repair prompt lacks %q:\n%s", want, repair)
+		}
+	}
+}
+
+func TestInterpretWithLLMNotJSON(t *testing.T) {
+	p := withStub(t, "sorry, I can't help with that",
+		`{"from":{"country":"KR"},"to":{"country":"JP"},"parcel":{"weight_kg":3}}`)
+	q, invalid, err := interpretWithLLM(context.Background(), p, "서울에서 도쿄로 3kg")
+	if err != nil || len(invalid) > 0 || getPath(q, "to.country") != "JP" {
+		t.Fatalf("q=%+v err=%v invalid=%v", q, err, invalid)
+	}
+	if !strings.Contains(p.Requests[1].User, "not a JSON object") {
+		t.Errorf("repair prompt = %s", p.Requests[1].User)
+	}
+}
+
+// 끝내 틀린 필드는 빠지고, interpretSession이 그 필드만 규칙 해석으로 채운다
+func TestInterpretStillInvalidMergedWithRules(t *testing.T) {
+	bad := `{"from":{"country":"KR"},"to":{"country":"USA"},"parcel":{"weight_kg":"heavy"},"currency":"KRW"}`
+	p := withStub(t, bad, bad, bad)
+	utterance := "서울에서 샌프란시스코로 2kg"
+
+	q, invalid, err := interpretWithLLM(context.Background(), p, utterance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Requests) != maxRepairs+1 {
+		t.Errorf("requests = %d, want %d", len(p.Requests), maxRepairs+1)
+	}
+	paths := map[string]bool{}
+	for _, e := range invalid {
+		paths[e.Path] = true
+	}
+	if !paths["to.country"] || !paths["parcel.weight_kg"] || len(paths) != 2 {
+		t.Errorf("invalid = %v", invalid)
+	}
+	if getPath(q, "to.country") != nil || getPath(q, "parcel.weight_kg") != nil || getPath(q, "from.country") != "KR" {
+		t.Errorf("invalid fields not dropped: %+v", q)
+	}
+
+	withStub(t, bad, bad, bad)
+	res := interpretSession(context.Background(), &session{Utterances: []string{utterance}})
+	if res.Need != nil {
+		t.Fatalf("need = %+v", res.Need)
+	}
+	if getPath(res.Quote, "to.country") != "US" || getPath(res.Quote, "parcel.weight_kg") != 2.0 {
+		t.Errorf("quote = %+v", res.Quote)
+	}
+	if res.Provenance["from.country"].Source != a2a.SourceLLM || res.Provenance["to.country"].Source != a2a.SourceRule {
+		t.Errorf("provenance = %+v", res.Provenance)
+	}
+}
+
+// 준비된 응답을 다 쓰면 발화만 규칙 해석(재시도 요청의 직전 출력은 보지 않음)
+func TestStubFallsBackToRules(t *testing.T) {
+	p := newStubProvider()
+	user := repairPrompt("Utterance: 서울에서 도쿄로 1kg", `{"to":{"country":"US"}}`, []fieldError{{Path: "x", Msg: "y"}})
+	out, err := p.Complete(context.Background(), LLMRequest{User: user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, `"country":"JP"`) || strings.Contains(out, `"US"`) {
+		t.Errorf("out = %s", out)
+	}
+}
+
+type failingProvider struct{}
+
+func (failingProvider) Name() string { return "failing" }
+func (failingProvider) Complete(context.Context, LLMRequest) (string, error) {
+	return "", errors.New("upstream down")
+}
+
+// 공급자 오류 뒤 재시도 대기는 취소되면 바로 끝난다
+func TestInterpretWithLLMCanceledWhileBackingOff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err := interpretWithLLM(ctx, failingProvider{}, "서울에서 도쿄로 1kg")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v", err)
+	}
+	if d := time.Since(start); d > 250*time.Millisecond {
+		t.Errorf("took %v", d)
+	}
+}
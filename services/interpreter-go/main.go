@@ -5,9 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"log"
+	"maps"
 	"net/http"
 	"os"
-	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,51 +16,57 @@ import (
 	a2a "a2a/contract"
 
 	"github.com/go-chi/chi/v5"
-	openai "github.com/sashabaranov/go-openai"
 )
 
 // ====== A2A task store ======
 type store struct {
-	mu sync.Mutex
-	m  map[string]*a2a.Task
+	mu       sync.Mutex
+	m        map[string]*a2a.Task
+	sessions map[string]*session // INPUT_REQUIRED 대화 누적
 }
 
-var st = store{m: map[string]*a2a.Task{}}
+var st = store{m: map[string]*a2a.Task{}, sessions: map[string]*session{}}
 
 // ====== QUOTE.input 스키마 ======
+// schema 태그: map 필드의 속성(이름:타입) — 도구 호출 모드의 함수 스키마가 여기서 나온다(tools.go)
 type QuoteInput struct {
-	From     map[string]any `json:"from"`
-	To       map[string]any `json:"to"`
-	Parcel   map[string]any `json:"parcel"`
-	Options  map[string]any `json:"options,omitempty"`
+	From     map[string]any `json:"from" schema:"country:string,postal:string,city:string"`
+	To       map[string]any `json:"to" schema:"country:string,postal:string,city:string"`
+	Parcel   map[string]any `json:"parcel" schema:"weight_kg:number,l_cm:number,w_cm:number,h_cm:number"`
+	Options  map[string]any `json:"options,omitempty" schema:"priority:boolean"`
 	Currency string         `json:"currency,omitempty"`
 	MaxWait  int            `json:"max_wait_ms,omitempty"`
 }
 
-// ====== LLM 클라이언트 ======
-type LLMClient struct {
-	c     *openai.Client
-	model string
-}
-
-func newLLM() *LLMClient {
-	cfg := openai.DefaultConfig(os.Getenv("OPENAI_API_KEY"))
-	if base := os.Getenv("OPENAI_BASE_URL"); base != "" {
-		cfg.BaseURL = base
-	}
-	return &LLMClient{
-		c:     openai.NewClientWithConfig(cfg),
-		model: getenv("OPENAI_MODEL", "gpt-4o-mini"),
-	}
-}
+// llm: 설정된 LLM 공급자(nil이면 규칙 해석만)
+var llm LLMProvider
 
 func main() {
 	agentID := getenv("AGENT_ID", "agent.interpreter-go")
+	var err error
+	if llm, err = newProvider(); err != nil {
+		log.Fatal(err)
+	}
+	if llmMode != "json" && llmMode != "tools" {
+		log.Fatalf("unknown LLM_MODE %q", llmMode)
+	}
+	if llm != nil {
+		log.Printf("LLM provider: %s (mode %s)", llm.Name(), modeOf(llm))
+		if llmMode == "tools" && toolsOf(llm) == nil {
+			log.Println("LLM provider has no tool calling → JSON mode")
+		}
+	}
 
 	r := chi.NewRouter()
+	r.Use(a2a.DeadlineMiddleware)
 
 	r.Get("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.Write([]byte("ok")) })
 
+	// LLM 해석 캐시 적중/실패 수
+	r.Get("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"llm_cache": cache.snapshot()})
+	})
+
 	// Discovery
 	r.Get("/.well-known/agent.json", func(w http.ResponseWriter, _ *http.Request) {
 		meta := a2a.AgentMeta{
@@ -90,7 +97,9 @@ func main() {
 		}
 
 		var in struct {
-			Utterance string `json:"utterance"`
+			Utterance     string   `json:"utterance"`
+			MinConfidence *float64 `json:"min_confidence"`    // 필수 필드 확신도 하한(기본 INTERPRET_MIN_CONFIDENCE)
+			OnLow         string   `json:"on_low_confidence"` // ask | refuse
 		}
 		if err := json.Unmarshal(ct.Input, &in); err != nil || strings.TrimSpace(in.Utterance) == "" {
 			w.WriteHeader(400)
@@ -98,37 +107,80 @@ func main() {
 			return
 		}
 
-		// LLM 기반 해석 시도 → 실패 시 규칙기반 폴백
-		var (
-			out QuoteInput
-			err error
-		)
-		if os.Getenv("OPENAI_API_KEY") != "" || os.Getenv("OPENAI_BASE_URL") != "" {
-			out, err = interpretWithLLM(context.Background(), newLLM(), in.Utterance)
-		} else {
-			err = errors.New("no LLM configured")
+		// 해석 — 출발/도착/무게가 확정되지 않으면 INPUT_REQUIRED로 되묻는다
+		taskID := a2a.NewID("t_interp_")
+		sess := &session{Utterances: []string{in.Utterance}, Data: structuredInput(in.Utterance), MinConfidence: defaultMinConfidence, OnLow: in.OnLow}
+		if in.MinConfidence != nil {
+			sess.MinConfidence = *in.MinConfidence
 		}
-		if err != nil {
-			log.Println("[Interpreter] LLM failed → fallback:", err)
-			out = interpretFallback(in.Utterance)
+		t := &a2a.Task{TaskID: taskID, TaskType: ct.TaskType, ContextID: ct.ContextID, CreatedAt: time.Now().UTC()}
+		resolveTask(r.Context(), t, sess)
+
+		st.mu.Lock()
+		st.m[taskID] = t
+		st.sessions[taskID] = sess
+		st.mu.Unlock()
+		resp := map[string]any{"task_id": taskID, "status": t.Status}
+		if t.InputRequired != nil {
+			resp["input_required"] = t.InputRequired
 		}
+		_ = json.NewEncoder(w).Encode(resp)
+	})
 
-		// 기본값 보정
-		if out.Currency == "" {
-			out.Currency = "KRW"
+	// 후속 답변 — INPUT_REQUIRED task에 발화/필드 값을 누적하고 다시 해석
+	r.Post("/tasks/{id}/messages", func(w http.ResponseWriter, r *http.Request) {
+		id := chi.URLParam(r, "id")
+		var m a2a.Message
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
 		}
-		if out.MaxWait == 0 {
-			out.MaxWait = 1200
+		if err := a2a.ValidateMessage(&m); err != nil {
+			w.WriteHeader(400)
+			_ = json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrValidationFailed, err.Error()))
+			return
 		}
-
-		resultBytes, _ := json.Marshal(out)
-		taskID := "t_interp_" + RandID()
-		t := &a2a.Task{TaskID: taskID, Status: a2a.StatusSucceeded, Result: resultBytes}
-
+		// 해석(LLM 호출)은 잠금 밖에서 — task/session 사본으로 풀고, 그 사이 다른 답이 먼저 반영됐으면 409
 		st.mu.Lock()
-		st.m[taskID] = t
+		t, ok := st.m[id]
+		var sess *session
+		if ok {
+			sess = st.sessions[id].clone()
+		}
 		st.mu.Unlock()
-		_ = json.NewEncoder(w).Encode(map[string]any{"task_id": taskID, "status": t.Status})
+		if !ok {
+			w.WriteHeader(404)
+			_ = json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrNotFound, "task not found"))
+			return
+		}
+		if t.Status != a2a.StatusInputRequired {
+			w.WriteHeader(409)
+			_ = json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrConflict, "task is "+string(t.Status)+", not INPUT_REQUIRED"))
+			return
+		}
+		if m.Text != "" && !sess.confirm(m.Text) {
+			sess.Utterances = append(sess.Utterances, m.Text)
+		}
+		for k, v := range m.Data {
+			if sess.Data == nil {
+				sess.Data = map[string]any{}
+			}
+			sess.Data[k] = v
+		}
+		cp := *t
+		resolveTask(r.Context(), &cp, sess)
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		if st.m[id] != t {
+			w.WriteHeader(409)
+			_ = json.NewEncoder(w).Encode(a2a.NewError(a2a.ErrConflict, "task was updated concurrently; retry"))
+			return
+		}
+		st.m[id] = &cp
+		st.sessions[id] = sess
+		_ = json.NewEncoder(w).Encode(&cp)
 	})
 
 	// GetTask
@@ -152,98 +204,120 @@ func main() {
 	_ = http.ListenAndServe(":8083", r)
 }
 
+// resolveTask: 대화를 해석해 task를 SUCCEEDED(결과 + provenance), INPUT_REQUIRED(질문) 또는 FAILED(확신도 미달 거절)로 갱신
+func resolveTask(ctx context.Context, t *a2a.Task, sess *session) {
+	res := interpretSession(ctx, sess)
+	sess.Asked = nil
+	switch {
+	case res.Refused != nil:
+		t.Status, t.InputRequired, t.Result, t.Error = a2a.StatusFailed, nil, nil, res.Refused
+	case res.Need != nil:
+		t.Status, t.InputRequired, t.Result = a2a.StatusInputRequired, res.Need, nil
+		sess.Asked = res.Need.Fields
+	default:
+		t.Status, t.InputRequired = a2a.StatusSucceeded, nil
+		t.Result, _ = json.Marshal(struct {
+			QuoteInput
+			Provenance map[string]a2a.Provenance `json:"provenance"`
+		}{res.Quote, res.Provenance})
+	}
+}
+
+// defaultMinConfidence: INTERPRET 입력에 min_confidence가 없을 때(0이면 보지 않음)
+var defaultMinConfidence, _ = strconv.ParseFloat(getenv("INTERPRET_MIN_CONFIDENCE", "0"), 64)
+
 // ====== LLM 해석 ======
 
-func interpretWithLLM(ctx context.Context, llm *LLMClient, utterance string) (QuoteInput, error) {
-	// JSON 전용 출력 요구 (OpenAI JSON 모드/함수호출 없이도 잘 동작)
-	sys := `You are a shipping quote input parser.
-Extract a strict JSON object matching this schema:
-{
-  "from": {"country": "ISO2", "postal": "string?"},
-  "to":   {"country": "ISO2", "postal": "string?"},
-  "parcel": {"weight_kg": number, "l_cm": number, "w_cm": number, "h_cm": number},
-  "options": {"priority": boolean?},
-  "currency": "KRW|USD|JPY|..." ,
-  "max_wait_ms": number
-}
-Rules:
-- Guess sensible defaults if unspecified (l=30,w=20,h=15, currency=KRW, max_wait_ms=1200).
+// promptRules: JSON 모드/도구 호출 모드 공통 해석 규칙
+const promptRules = `Rules:
+- Guess sensible defaults for dimensions, currency and max_wait_ms if unspecified (l=30,w=20,h=15, currency=KRW, max_wait_ms=1200).
 - Countries: map '한국/대한민국/서울'→KR, '미국/샌프란시스코/USA'→US.
-- If ambiguous, choose likely values; DO NOT add commentary; output JSON only.`
+- NEVER guess origin country, destination country or weight: if not stated or ambiguous, use null.
+- The utterance may contain several lines (follow-up answers); later lines refine earlier ones.
+`
 
+// quotePrompt: JSON 모드 시스템 프롬프트. JSON 전용 출력 요구 (OpenAI JSON 모드/함수호출 없이도 잘 동작).
+// 스키마는 pkg/a2a/schemas의 파일을 그대로 싣는다. 바꾸면 promptVersion이 바뀌어 캐시된 해석이 무효가 된다.
+var quotePrompt = `You are a shipping quote input parser.
+Extract a strict JSON object matching this JSON Schema (use null for values that are not stated):
+` + promptSchema() + `
+` + promptRules + `- DO NOT add commentary; output JSON only.`
+
+// promptSchema: 스키마 파일에서 $schema/$id를 뺀 들여쓴 JSON
+func promptSchema() string {
+	s := maps.Clone(quoteSchema)
+	delete(s, "$schema")
+	delete(s, "$id")
+	b, _ := json.MarshalIndent(s, "", "  ")
+	return string(b)
+}
+
+// 스키마 검사에 실패하면 오류를 알려 주고 다시 묻는 횟수
+const maxRepairs = 2
+
+// interpretWithLLM: LLM 해석 + 스키마 검사. 틀리면 오류를 붙여 다시 묻고(최대 maxRepairs번),
+// 끝내 틀리면 틀린 필드를 뺀 결과와 남은 오류를 돌려준다. err는 쓸 만한 JSON을 하나도 못 받았을 때.
+func interpretWithLLM(ctx context.Context, p LLMProvider, utterance string) (QuoteInput, []fieldError, error) {
 	usr := "Utterance: " + utterance
 
-	req := openai.ChatCompletionRequest{
-		Model: llm.model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: openai.ChatMessageRoleSystem, Content: sys},
-			{Role: openai.ChatMessageRoleUser, Content: usr},
-		},
-		// JSON-mode: 최신 모델들은 ResponseFormat 설정 또는 시스템 지시로 JSON만 출력 유도
-		ResponseFormat: &openai.ChatCompletionResponseFormat{
-			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
-		},
-		Temperature: 0.1,
+	req := LLMRequest{System: quotePrompt, User: usr, Temperature: 0.1}
+	complete := p.Complete
+	if tc := toolsOf(p); tc != nil {
+		req.System = toolPrompt
+		complete = func(ctx context.Context, req LLMRequest) (string, error) { return tc.CompleteTool(ctx, req, quoteTool) }
 	}
 
-	// 간단 재시도
-	var lastErr error
-	for i := 0; i < 2; i++ {
-		resp, err := llm.c.CreateChatCompletion(ctx, req)
+	var (
+		lastErr error
+		raw     map[string]any
+		invalid []fieldError
+	)
+attempts:
+	for i := 0; i <= maxRepairs; i++ {
+		txt, err := complete(ctx, req)
 		if err != nil {
 			lastErr = err
-			time.Sleep(300 * time.Millisecond)
-			continue
-		}
-		if len(resp.Choices) == 0 {
-			lastErr = errors.New("no choices")
+			select {
+			case <-ctx.Done():
+				lastErr = errors.Join(lastErr, ctx.Err())
+				break attempts // 이미 받은 출력이 있으면 그걸로
+			case <-time.After(300 * time.Millisecond):
+			}
 			continue
 		}
-		txt := resp.Choices[0].Message.Content
-
-		var out QuoteInput
-		if err := json.Unmarshal([]byte(txt), &out); err != nil {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(jsonObject(txt)), &m); err != nil {
 			lastErr = err
+			req.User = repairPrompt(usr, txt, []fieldError{{Path: "$", Msg: "not a JSON object: " + err.Error()}})
 			continue
 		}
-		// 간단 검증
-		if out.From == nil || out.To == nil || out.Parcel == nil {
-			lastErr = errors.New("missing required fields")
-			continue
+		raw, invalid = m, validateQuote(m)
+		if len(invalid) == 0 {
+			return withoutInvalid(raw, nil), nil, nil // 빠진 필드는 missingFields가 되묻는다
 		}
-		return out, nil
+		log.Printf("[Interpreter] LLM output invalid (attempt %d): %v", i+1, invalid)
+		req.User = repairPrompt(usr, txt, invalid)
+	}
+	if raw == nil {
+		return QuoteInput{}, nil, lastErr
 	}
-	return QuoteInput{}, lastErr
+	return withoutInvalid(raw, invalid), invalid, nil
 }
 
-// ====== 규칙 기반 폴백 ======
+const repairMarker = "\n\nYour previous output:\n"
 
-func interpretFallback(u string) QuoteInput {
-	s := strings.ToLower(u)
-	weight := 1.0
-	re := regexp.MustCompile(`(\d+(\.\d+)?)\s*kg`)
-	if m := re.FindStringSubmatch(s); len(m) > 0 {
-		var f float64
-		_ = json.Unmarshal([]byte(m[1]), &f)
-		weight = f
-	}
-	from := map[string]any{"country": "KR"}
-	to := map[string]any{"country": "US"}
-	if strings.Contains(s, "한국") || strings.Contains(s, "서울") {
-		from["country"] = "KR"
-	}
-	if strings.Contains(s, "미국") || strings.Contains(s, "샌프란시스코") || strings.Contains(s, "usa") {
-		to["country"] = "US"
-	}
-	priority := strings.Contains(s, "빠른") || strings.Contains(s, "급") || strings.Contains(s, "express")
-	return QuoteInput{
-		From:     from,
-		To:       to,
-		Parcel:   map[string]any{"weight_kg": weight, "l_cm": 30, "w_cm": 20, "h_cm": 15},
-		Options:  map[string]any{"priority": priority},
-		Currency: "KRW",
-		MaxWait:  1200,
+// repairPrompt: 원래 요청 + 직전 출력 + 스키마 위반 목록
+func repairPrompt(usr, prev string, invalid []fieldError) string {
+	var sb strings.Builder
+	sb.WriteString(usr)
+	sb.WriteString(repairMarker)
+	sb.WriteString(prev)
+	sb.WriteString("\n\nIt does not match the schema:\n")
+	for _, e := range invalid {
+		sb.WriteString("- " + e.String() + "\n")
 	}
+	sb.WriteString("Return the corrected JSON object only. Use null for values that are not stated.")
+	return sb.String()
 }
 
 // ====== 유틸 ======
@@ -253,4 +327,3 @@ func getenv(k, def string) string {
 	}
 	return def
 }
-func RandID() string { return time.Now().Format("150405") } // 데모용
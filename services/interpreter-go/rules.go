@@ -0,0 +1,309 @@
+package main
+
+import (
+	_ "embed"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	a2a "a2a/contract"
+)
+
+// ====== 규칙 기반 해석(LLM이 없거나 실패했을 때) ======
+//
+// 지명(gazetteer.txt — 한/영/일 국가·도시명) + 방향 표지로 출발/도착을 정하고,
+// 무게/크기(단위 환산), 우편번호, 통화, 우선 처리 키워드를 읽는다.
+// 출발/도착/무게는 찾은 것만 채운다 — 나머지는 missingFields가 되묻는다.
+
+//go:embed gazetteer.txt
+var gazetteerTxt string
+
+// place: 지명 하나가 가리키는 국가
+type place struct {
+	code string // ISO2
+	city bool
+}
+
+// 지명(소문자) → 국가
+var gazetteer = loadGazetteer(gazetteerTxt)
+
+func loadGazetteer(src string) map[string]place {
+	m := map[string]place{}
+	for _, line := range strings.Split(src, "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		for i, col := range cols[1:] {
+			for _, name := range strings.Split(col, ",") {
+				if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+					m[name] = place{code: cols[0], city: i == 1}
+				}
+			}
+		}
+	}
+	return m
+}
+
+const num = `(\d+(?:\.\d+)?)`
+
+var (
+	reWeight = regexp.MustCompile(num + `\s*(kgs?\b|킬로그램|킬로|키로|キロ|lbs?\b|pounds?\b|파운드|ポンド|oz\b|ounces?\b|온스|オンス|g\b|그램|グラム)`)
+	reNumber = regexp.MustCompile(`^\s*` + num + `\s*$`)
+	// 30x20x15cm, 12 × 8 × 4 in
+	reDims = regexp.MustCompile(num + `\s*[x×*]\s*` + num + `\s*[x×*]\s*` + num + `\s*(cm\b|mm\b|inch(?:es)?\b|in\b|인치|센티(?:미터)?|"|″)?`)
+	// 가로 30 세로 20 높이 15, length: 12in
+	reSide   = regexp.MustCompile(`(가로|세로|높이|길이|폭|너비|length|width|height|長さ|縦|横|幅|高さ)\s*[:=]?\s*` + num + `\s*(cm\b|mm\b|inch(?:es)?\b|in\b|인치|센티(?:미터)?)?`)
+	rePostal = regexp.MustCompile(`(?:우편번호|postal\s*code|post\s*code|postcode|zip(?:\s*code)?|郵便番号|〒)\s*[:#]?\s*([0-9]{3}-[0-9]{4}|[0-9]{5}(?:-[0-9]{4})?|[a-z]{1,2}[0-9][a-z0-9]?\s?[0-9][a-z]{2})`)
+
+	// 지명 뒤 방향 표지: 에서/발/から → 출발, (으)로/까지/행/まで/へ → 도착 ("에서"를 "에"보다 먼저 본다)
+	fromSuffix = []string{"에서", "발", "부터", "から"}
+	toSuffix   = []string{"으로", "로", "까지", "행", "에", "まで", "へ", "向け"}
+
+	sideKeys = map[string]string{
+		"가로": "l_cm", "길이": "l_cm", "length": "l_cm", "長さ": "l_cm", "横": "l_cm",
+		"세로": "w_cm", "폭": "w_cm", "너비": "w_cm", "width": "w_cm", "縦": "w_cm", "幅": "w_cm",
+		"높이": "h_cm", "height": "h_cm", "高さ": "h_cm",
+	}
+
+	// 통화 언급 — 가장 먼저 나온 것
+	currencies = []struct {
+		code string
+		re   *regexp.Regexp
+	}{
+		{"USD", regexp.MustCompile(`\busd\b|us\$|달러|\bdollars?\b|ドル|\$`)},
+		{"JPY", regexp.MustCompile(`\bjpy\b|엔화|\d\s*엔|\byen\b|円`)},
+		{"EUR", regexp.MustCompile(`\beur\b|유로|\beuros?\b|ユーロ|€`)},
+		{"GBP", regexp.MustCompile(`\bgbp\b|파운드화|\bsterling\b|£`)},
+		{"CNY", regexp.MustCompile(`\bcny\b|\brmb\b|위안|人民元`)},
+		{"KRW", regexp.MustCompile(`\bkrw\b|원화|\d\s*[만천]?\s*원|\bwon\b|ウォン|₩`)},
+	}
+
+	priorityWords = []string{"빠른", "빨리", "급송", "급히", "급해", "긴급", "특급", "익일", "당일",
+		"express", "urgent", "asap", "priority", "rush", "overnight", "至急", "速達", "急ぎ"}
+)
+
+type placeHit struct {
+	pos, end int
+	code     string
+	city     string // 도시명으로 찾았으면 원문 표기
+	dir      string // from | to | ""
+	side     string // 최종 배정: from | to
+}
+
+// 규칙 해석 확신도 — 단위/방향 표지가 분명하면 높고, 순서로 짐작한 지명은 낮다
+const (
+	confExplicit = 0.95 // 단위가 붙은 무게
+	confMarked   = 0.9  // 방향 표지가 붙은 지명, 크기, 통화, 되묻기에 대한 답
+	confHint     = 0.8  // 우편번호, 우선 처리 키워드, 숫자만 있는 답
+	confOrder    = 0.6  // 표지 없이 나온 순서로 출발/도착을 정한 지명
+)
+
+// interpretRules: 규칙으로 해석. 애매한 필드는 후보와 함께, 필드별 확신도(경로 → 0~1)도 반환.
+func interpretRules(text string) (QuoteInput, []a2a.FieldRequest, map[string]float64) {
+	s := strings.ToLower(text)
+	if len(s) != len(text) {
+		text = s // 대소문자 변환으로 길이가 바뀌면 원문 위치를 못 쓴다
+	}
+	var q QuoteInput
+	var amb []a2a.FieldRequest
+	conf := map[string]float64{}
+	set := func(section, key string, v any, c float64) {
+		m := sectionOf(&q, section)
+		if *m == nil {
+			*m = map[string]any{}
+		}
+		(*m)[key] = v
+		conf[section+"."+key] = c
+	}
+	// 첫 줄 뒤는 되묻기에 대한 답
+	firstLine, _, _ := strings.Cut(s, "\n")
+
+	// 무게 — 줄 단위로 보고(뒤 줄이 앞 줄을 덮음), 단위 없는 숫자만 있는 줄(되묻기에 대한 답)은 kg으로 본다
+	for _, line := range strings.Split(s, "\n") {
+		if m := reWeight.FindStringSubmatch(line); m != nil {
+			set("parcel", "weight_kg", toKg(m[1], m[2]), confExplicit)
+		} else if m := reNumber.FindStringSubmatch(line); m != nil && getPath(q, "parcel.weight_kg") == nil {
+			f, _ := strconv.ParseFloat(m[1], 64)
+			set("parcel", "weight_kg", f, confHint)
+		}
+	}
+
+	// 크기
+	if m := reDims.FindStringSubmatch(s); m != nil {
+		for i, k := range []string{"l_cm", "w_cm", "h_cm"} {
+			set("parcel", k, toCm(m[i+1], m[4]), confMarked)
+		}
+	}
+	for _, m := range reSide.FindAllStringSubmatch(s, -1) {
+		set("parcel", sideKeys[m[1]], toCm(m[2], m[3]), confMarked)
+	}
+
+	// 지명 — 위치 순서대로(겹치면 긴 것), 방향 표지가 있으면 우선
+	hits := findPlaces(s, text)
+	var bare []*placeHit
+	var from, to *placeHit
+	for i := range hits {
+		h := &hits[i]
+		switch h.dir {
+		case "from":
+			from = h
+		case "to":
+			to = h
+		default:
+			bare = append(bare, h)
+		}
+	}
+	// 표지 없는 지명은 비어 있는 쪽을 순서대로 채움. 둘 다 비었는데 하나뿐이면 애매함.
+	switch {
+	case from == nil && to == nil && len(bare) == 1:
+		for _, f := range []string{"from.country", "to.country"} {
+			amb = append(amb, a2a.FieldRequest{Field: f, Reason: a2a.FieldAmbiguous, Candidates: []string{bare[0].code},
+				Question: bare[0].code + "은(는) 출발지인가요, 도착지인가요?"})
+		}
+	default:
+		for _, h := range bare {
+			if from == nil {
+				from = h
+			} else if to == nil {
+				to = h
+			}
+		}
+	}
+	for side, h := range map[string]*placeHit{"from": from, "to": to} {
+		if h == nil {
+			continue
+		}
+		h.side = side
+		c := confOrder
+		if h.dir != "" || h.pos > len(firstLine) {
+			c = confMarked
+		}
+		set(side, "country", h.code, c)
+		if h.city != "" {
+			set(side, "city", h.city, c)
+		}
+	}
+
+	// 우편번호 — 바로 앞에 나온 (배정된) 지명 쪽, 없으면 출발지
+	for _, m := range rePostal.FindAllStringSubmatchIndex(s, -1) {
+		side := "from"
+		for _, h := range hits {
+			if h.pos < m[0] && h.side != "" {
+				side = h.side
+			}
+		}
+		set(side, "postal", strings.ToUpper(s[m[2]:m[3]]), confHint)
+	}
+
+	// 통화
+	first := len(s)
+	for _, c := range currencies {
+		if loc := c.re.FindStringIndex(s); loc != nil && loc[0] < first {
+			first, q.Currency = loc[0], c.code
+			conf["currency"] = confMarked
+		}
+	}
+
+	for _, w := range priorityWords {
+		if strings.Contains(s, w) {
+			set("options", "priority", true, confHint)
+			break
+		}
+	}
+	return q, amb, conf
+}
+
+// findPlaces: gazetteer 지명 위치. 겹치는 것 중에는 먼저 시작하고 긴 것(“south korea” > “korea”).
+func findPlaces(s, text string) []placeHit {
+	var hits []placeHit
+	for name, p := range gazetteer {
+		for i := 0; ; {
+			j := strings.Index(s[i:], name)
+			if j < 0 {
+				break
+			}
+			pos := i + j
+			i = pos + len(name)
+			if !wordBoundary(s, pos, i) {
+				continue
+			}
+			h := placeHit{pos: pos, end: i, code: p.code, dir: direction(s, pos, i)}
+			if p.city {
+				h.city = text[pos:i]
+			}
+			hits = append(hits, h)
+		}
+	}
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].pos != hits[b].pos {
+			return hits[a].pos < hits[b].pos
+		}
+		return hits[a].end > hits[b].end
+	})
+	out := hits[:0]
+	end := 0
+	for _, h := range hits {
+		if h.pos >= end {
+			out = append(out, h)
+			end = h.end
+		}
+	}
+	return out
+}
+
+func toKg(v, unit string) float64 {
+	f, _ := strconv.ParseFloat(v, 64)
+	switch unit {
+	case "g", "그램", "グラム":
+		f /= 1000
+	case "lb", "lbs", "pound", "pounds", "파운드", "ポンド":
+		f *= 0.45359237
+	case "oz", "ounce", "ounces", "온스", "オンス":
+		f *= 0.028349523
+	}
+	return math.Round(f*1000) / 1000
+}
+
+func toCm(v, unit string) float64 {
+	f, _ := strconv.ParseFloat(v, 64)
+	switch unit {
+	case "mm":
+		f /= 10
+	case "in", "inch", "inches", "인치", `"`, "″":
+		f *= 2.54
+	}
+	return math.Round(f*10) / 10
+}
+
+// wordBoundary: 영문 지명이 다른 단어의 일부(예: "plan"의 "la")가 아닌지
+func wordBoundary(s string, start, end int) bool {
+	isAlpha := func(c byte) bool { return c >= 'a' && c <= 'z' }
+	if isAlpha(s[start]) && start > 0 && isAlpha(s[start-1]) {
+		return false
+	}
+	return !(isAlpha(s[end-1]) && end < len(s) && isAlpha(s[end]))
+}
+
+func direction(s string, start, end int) string {
+	rest, before := s[end:], strings.TrimSpace(s[:start])
+	for _, p := range fromSuffix {
+		if strings.HasPrefix(rest, p) {
+			return "from"
+		}
+	}
+	for _, p := range toSuffix {
+		if strings.HasPrefix(rest, p) {
+			return "to"
+		}
+	}
+	switch {
+	case strings.HasSuffix(before, "from"):
+		return "from"
+	case strings.HasSuffix(before, "to"), strings.HasSuffix(before, "→"), strings.HasSuffix(before, "->"):
+		return "to"
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	a2a "a2a/contract"
+)
+
+func TestInterpretRules(t *testing.T) {
+	cases := []struct {
+		text string
+		want map[string]any // 경로 → 값(nil이면 없어야 함)
+	}{
+		{"30x20x15cm 상자", map[string]any{"parcel.l_cm": 30.0, "parcel.w_cm": 20.0, "parcel.h_cm": 15.0}},
+		{"12 × 8 × 4 in", map[string]any{"parcel.l_cm": 30.5, "parcel.w_cm": 20.3, "parcel.h_cm": 10.2}},
+		{"가로 30 세로 20", map[string]any{"parcel.l_cm": 30.0, "parcel.w_cm": 20.0, "parcel.h_cm": nil}},
+		{"length: 10 inch, height 5in", map[string]any{"parcel.l_cm": 25.4, "parcel.h_cm": 12.7}},
+		{"3 lb", map[string]any{"parcel.weight_kg": 1.361}},
+		{"16oz", map[string]any{"parcel.weight_kg": 0.454}},
+		{"500g", map[string]any{"parcel.weight_kg": 0.5}},
+		{"2kg", map[string]any{"parcel.weight_kg": 2.0}},
+		{"서울에서 LA로", map[string]any{"from.country": "KR", "from.city": "서울", "to.country": "US", "to.city": "LA"}},
+		{"from Tokyo to Paris", map[string]any{"from.country": "JP", "to.country": "FR"}},
+		{"LA로 서울에서", map[string]any{"from.country": "KR", "to.country": "US"}}, // 순서보다 방향 표지
+		{"서울 우편번호 04524에서 뉴욕 zip 10001로", map[string]any{"from.postal": "04524", "to.postal": "10001"}},
+		{"to London postcode SW1A 1AA", map[string]any{"to.country": "GB", "to.postal": "SW1A 1AA"}},
+		{"〒100-0001 도쿄에서 미국으로", map[string]any{"from.postal": "100-0001", "from.country": "JP", "to.country": "US"}},
+		{"explain the plan", map[string]any{"from.country": nil, "to.country": nil}}, // "plan"의 "la"는 지명 아님
+	}
+	for _, c := range cases {
+		q, amb, _ := interpretRules(c.text)
+		if len(amb) > 0 {
+			t.Errorf("%q: ambiguous %+v", c.text, amb)
+		}
+		for path, want := range c.want {
+			if got := getPath(q, path); got != want {
+				t.Errorf("%q: %s = %#v, want %#v", c.text, path, got, want)
+			}
+		}
+	}
+}
+
+// 표지 없는 지명 하나는 출발지인지 도착지인지 모른다 — 채우지 않고 둘 다 되묻는다
+func TestInterpretRulesBarePlaceAmbiguous(t *testing.T) {
+	for _, text := range []string{"서울", "tokyo 2kg"} {
+		q, amb, _ := interpretRules(text)
+		if getPath(q, "from.country") != nil || getPath(q, "to.country") != nil {
+			t.Errorf("%q: guessed a side: %+v", text, q)
+		}
+		fields := map[string]bool{}
+		for _, a := range amb {
+			if a.Reason != a2a.FieldAmbiguous || len(a.Candidates) != 1 {
+				t.Errorf("%q: %+v", text, a)
+			}
+			fields[a.Field] = true
+		}
+		if !fields["from.country"] || !fields["to.country"] {
+			t.Errorf("%q: ambiguous = %+v", text, amb)
+		}
+	}
+}
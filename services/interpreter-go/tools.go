@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// ====== 도구 호출(tool calling) 모드 ======
+//
+// LLM_MODE = json(기본) | tools.
+// json: response format JSON + 스키마를 적은 긴 시스템 프롬프트(quotePrompt).
+// tools: QuoteRequest를 함수 스키마(QuoteInput 구조체 + 스키마 파일의 설명/제약으로 생성)로 선언하고 호출을 강제해 그 인자를 해석 결과로 쓴다.
+// 도구 호출이 없는 공급자(stub)는 tools로 설정해도 JSON 모드로 돌아간다.
+
+var llmMode = strings.ToLower(getenv("LLM_MODE", "json"))
+
+// toolCaller: 도구 호출을 지원하는 공급자 — 호출 인자(JSON 객체 텍스트)를 돌려준다.
+// 모델이 도구 대신 텍스트로 답하면 그 텍스트를 돌려준다(JSON 모드와 같은 파싱/되묻기).
+type toolCaller interface {
+	CompleteTool(ctx context.Context, req LLMRequest, tool ToolSpec) (string, error)
+}
+
+// ToolSpec: 함수 이름/설명 + 인자 JSON Schema
+type ToolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}
+
+var quoteTool = ToolSpec{
+	Name:        "quote_request",
+	Description: "Record the shipping quote request stated in the utterance. Use null for anything not stated.",
+	Parameters:  toolSchema(reflect.TypeFor[QuoteInput](), ""),
+}
+
+// toolPrompt: 도구 호출 모드 시스템 프롬프트(스키마는 도구 선언이 대신한다)
+const toolPrompt = `You are a shipping quote input parser. Call quote_request exactly once with the values in the utterance.
+` + promptRules
+
+// toolsOf: 도구 호출 모드로 쓸 수 있으면 그 공급자, 아니면 nil(JSON 모드)
+func toolsOf(p LLMProvider) toolCaller {
+	if tc, ok := p.(toolCaller); ok && llmMode == "tools" {
+		return tc
+	}
+	return nil
+}
+
+func modeOf(p LLMProvider) string {
+	if toolsOf(p) != nil {
+		return "tools"
+	}
+	return "json"
+}
+
+// 스키마 파일에서 도구 선언으로 옮기는 키워드(모양/타입은 구조체에서, 설명/제약은 파일에서)
+var toolSchemaKeywords = []string{"description", "pattern", "enum", "minimum", "exclusiveMinimum"}
+
+// toolSchema: 구조체 → JSON Schema. 속성 이름은 json 태그, map 필드의 속성은 schema 태그("이름:타입,...").
+// map 속성은 모르면 null을 허용한다(되묻기 대상).
+func toolSchema(t reflect.Type, prefix string) map[string]any {
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		var s map[string]any
+		switch f.Type.Kind() {
+		case reflect.Map:
+			sub := map[string]any{}
+			for _, p := range strings.Split(f.Tag.Get("schema"), ",") {
+				k, typ, _ := strings.Cut(p, ":")
+				sub[k] = withDocs(prefix+name+"."+k, map[string]any{"type": []string{typ, "null"}})
+			}
+			s = map[string]any{"type": "object", "properties": sub}
+		case reflect.Struct:
+			s = toolSchema(f.Type, prefix+name+".")
+		case reflect.String:
+			s = map[string]any{"type": "string"}
+		case reflect.Int, reflect.Int64:
+			s = map[string]any{"type": "integer"}
+		case reflect.Float64:
+			s = map[string]any{"type": "number"}
+		case reflect.Bool:
+			s = map[string]any{"type": "boolean"}
+		default:
+			continue
+		}
+		props[name] = withDocs(prefix+name, s)
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+func withDocs(path string, s map[string]any) map[string]any {
+	def := schemaAt(path)
+	for _, k := range toolSchemaKeywords {
+		if v, ok := def[k]; ok {
+			s[k] = v
+		}
+	}
+	return s
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	a2a "a2a/contract"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// ====== LLM 출력 검사(QuoteRequest 스키마 — pkg/a2a/schemas/quote-request.schema.json) ======
+//
+// 스키마 파일이 원본이다: 검사(validateQuote), JSON 모드 프롬프트(quotePrompt), 도구 선언(quoteTool)이
+// 모두 이 파일에서 나온다. 출발/도착 국가와 무게는 모르면 null이 허용된다(되묻기 대상) —
+// null은 "말하지 않음"으로 보고 지운 뒤 검사하며, 빠진 필수 필드는 missingFields가 되묻는다.
+
+const quoteSchemaFile = "schemas/quote-request.schema.json"
+
+// fieldError: 스키마 위반 하나(경로 + 이유) — 그대로 모델에 되돌려준다
+type fieldError struct {
+	Path string `json:"path"`
+	Msg  string `json:"msg"`
+}
+
+func (e fieldError) String() string { return e.Path + ": " + e.Msg }
+
+var (
+	quoteSchemaJSON, _ = a2a.Schemas.ReadFile(quoteSchemaFile)
+	quoteSchema        = mustDecode(quoteSchemaJSON) // 프롬프트/도구 선언용
+	quoteValidator     = compileSchema(quoteSchemaFile, quoteSchemaJSON)
+	allowedCurrencies  = stringsOf(schemaAt("currency")["enum"])
+)
+
+// ISO 3166-1 alpha-2 — 스키마의 format "iso3166-alpha2"
+var iso2 = strings.Fields(`
+AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ
+CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR
+GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP
+KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT
+MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW
+SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG
+UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW`)
+
+func mustDecode(b []byte) map[string]any {
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		panic(fmt.Sprintf("%s: %v", quoteSchemaFile, err))
+	}
+	return m
+}
+
+func compileSchema(name string, b []byte) *jsonschema.Schema {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		panic(fmt.Sprintf("%s: %v", name, err))
+	}
+	c := jsonschema.NewCompiler()
+	c.RegisterFormat(&jsonschema.Format{Name: "iso3166-alpha2", Validate: func(v any) error {
+		if s, ok := v.(string); ok && !slices.Contains(iso2, s) {
+			return fmt.Errorf("%q is not an ISO 3166-1 alpha-2 code (e.g. KR, US)", s)
+		}
+		return nil
+	}})
+	c.AssertFormat()
+	if err := c.AddResource(name, doc); err != nil {
+		panic(err)
+	}
+	return c.MustCompile(name)
+}
+
+var msgPrinter = message.NewPrinter(language.English)
+
+// validateQuote: JSON으로 디코드한 LLM 출력 검사. 경로 순으로, 같은 경로의 위반은 한 줄로 합친다.
+func validateQuote(raw map[string]any) []fieldError {
+	err := quoteValidator.Validate(withoutNulls(raw))
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	msgs := map[string][]string{}
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, c := range e.Causes {
+				walk(c)
+			}
+			return
+		}
+		if _, missing := e.ErrorKind.(*kind.Required); missing {
+			return
+		}
+		path := strings.Join(e.InstanceLocation, ".")
+		if path == "" {
+			path = "$"
+		}
+		msgs[path] = append(msgs[path], e.ErrorKind.LocalizedString(msgPrinter))
+	}
+	walk(ve)
+	var errs []fieldError
+	for _, path := range slices.Sorted(maps.Keys(msgs)) {
+		slices.Sort(msgs[path])
+		errs = append(errs, fieldError{path, strings.Join(msgs[path], "; ")})
+	}
+	return errs
+}
+
+// withoutNulls: null 값을 뺀 사본(중첩 객체까지)
+func withoutNulls(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		switch v := v.(type) {
+		case nil:
+		case map[string]any:
+			out[k] = withoutNulls(v)
+		default:
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// schemaAt: 스키마 파일에서 경로(예: "parcel.l_cm")의 정의. $ref는 풀고, $ref 옆 키워드(description 등)가 우선한다.
+func schemaAt(path string) map[string]any {
+	s := quoteSchema
+	for _, k := range strings.Split(path, ".") {
+		props, _ := deref(s)["properties"].(map[string]any)
+		if s, _ = props[k].(map[string]any); s == nil {
+			return nil
+		}
+	}
+	return deref(s)
+}
+
+func deref(s map[string]any) map[string]any {
+	ref, ok := s["$ref"].(string)
+	if !ok {
+		return s
+	}
+	defs, _ := quoteSchema["$defs"].(map[string]any)
+	target, _ := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	out := maps.Clone(deref(target))
+	if out == nil {
+		out = map[string]any{}
+	}
+	for k, v := range s {
+		if k != "$ref" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func stringsOf(v any) []string {
+	var out []string
+	for _, x := range v.([]any) {
+		out = append(out, x.(string))
+	}
+	return out
+}
+
+// withoutInvalid: 틀린 필드를 지운 뒤 QuoteInput으로(타입이 틀린 값도 여기서 빠진다)
+func withoutInvalid(raw map[string]any, invalid []fieldError) QuoteInput {
+	for _, e := range invalid {
+		head, key, ok := strings.Cut(e.Path, ".")
+		if m, isMap := raw[head].(map[string]any); ok && isMap {
+			delete(m, key)
+		} else {
+			delete(raw, head)
+		}
+	}
+	var out QuoteInput
+	b, _ := json.Marshal(raw)
+	_ = json.Unmarshal(b, &out)
+	return out
+}
+
+// mergeRules: LLM 값이 없는 필드만 규칙 해석 값으로 채운다
+func mergeRules(out, rules QuoteInput) QuoteInput {
+	for _, name := range []string{"from", "to", "parcel", "options"} {
+		for k, v := range *sectionOf(&rules, name) {
+			if path := name + "." + k; getPath(out, path) == nil {
+				setPath(&out, path, v)
+			}
+		}
+	}
+	if out.Currency == "" {
+		out.Currency = rules.Currency
+	}
+	return out
+}
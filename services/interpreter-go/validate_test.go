@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateQuote(t *testing.T) {
+	cases := []struct {
+		in    string
+		paths []string
+	}{
+		{`{"from":{"country":"KR"},"to":{"country":"US"},"parcel":{"weight_kg":2,"l_cm":30},"currency":"KRW","max_wait_ms":1200}`, nil},
+		{`{"from":{"country":null},"to":null,"parcel":{"weight_kg":null}}`, nil}, // null = 말하지 않음(되묻기 대상)
+		{`{}`, nil}, // 빠진 필수 필드는 missingFields 몫
+		{`{"from":{"country":"Korea"},"to":{"country":"XX"}}`, []string{"from.country", "to.country"}},
+		{`{"parcel":{"weight_kg":0,"h_cm":"10"}}`, []string{"parcel.h_cm", "parcel.weight_kg"}},
+		{`{"to":"US","options":{"priority":"yes"}}`, []string{"options.priority", "to"}},
+		{`{"currency":"WON","max_wait_ms":1.5}`, []string{"currency", "max_wait_ms"}},
+		{`{"max_wait_ms":0}`, []string{"max_wait_ms"}},
+	}
+	for _, c := range cases {
+		var raw map[string]any
+		if err := json.Unmarshal([]byte(c.in), &raw); err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, e := range validateQuote(raw) {
+			paths = append(paths, e.Path)
+		}
+		if !reflect.DeepEqual(paths, c.paths) {
+			t.Errorf("%s: invalid = %v, want %v", c.in, validateQuote(raw), c.paths)
+		}
+	}
+}
+
+// 프롬프트/도구 선언이 스키마 파일에서 나오는지
+func TestSchemaSingleSource(t *testing.T) {
+	if !strings.Contains(quotePrompt, `"exclusiveMinimum": 0`) || !strings.Contains(quotePrompt, `"AUD"`) {
+		t.Errorf("quotePrompt lacks schema file constraints:\n%s", quotePrompt)
+	}
+	country := quoteTool.Parameters["properties"].(map[string]any)["to"].(map[string]any)["properties"].(map[string]any)["country"].(map[string]any)
+	if country["pattern"] != "^[A-Z]{2}$" || country["description"] == nil {
+		t.Errorf("tool to.country = %v", country)
+	}
+	if len(allowedCurrencies) == 0 || allowedCurrencies[0] != "KRW" {
+		t.Errorf("allowedCurrencies = %v", allowedCurrencies)
+	}
+}